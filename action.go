@@ -0,0 +1,271 @@
+package loupedeck
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Action is something a control can do when it's used, such as
+// running a command, sending an HTTP request, or switching pages.
+// Actions are run with RunAction, in their own goroutine, and should
+// stop promptly when ctx is cancelled.
+type Action interface {
+	Run(ctx context.Context, ac ActionContext) error
+}
+
+// QuietAction is implemented by actions whose effect is visible on
+// its own, such as switching pages.  When Quiet returns true, the
+// triggering key or button isn't flashed when the action finishes,
+// though failures are still logged.
+type QuietAction interface {
+	Action
+	Quiet() bool
+}
+
+// PollingAction is implemented by actions that should be run over
+// and over to keep a control up to date, such as a CommandAction
+// showing a status.  Profiles poll these actions with PollAction
+// while their page is shown.  A zero interval turns polling off.
+type PollingAction interface {
+	Action
+	PollInterval() time.Duration
+}
+
+// ActionContext describes where an action is being run.
+type ActionContext struct {
+	Device *Device
+	// Profile is the profile that the action belongs to, or nil.
+	// Page and value actions need it.
+	Profile *Profile
+	// Page is the page that the triggering control is on, or nil.
+	Page *Page
+	// Key is the touch key that triggered the action, or
+	// TouchNone.
+	Key TouchButton
+	// Button is the button that triggered the action, or 0.
+	Button Button
+}
+
+// ActionFactory creates an Action from its parameters in a profile.
+// decode fills in a struct from the parameters, as yaml.Unmarshal
+// would, and fails if there are parameters that the struct doesn't
+// have a field for.  The "type" parameter is always allowed.
+type ActionFactory func(decode func(params any) error) (Action, error)
+
+var actionRegistry = struct {
+	mutex     sync.RWMutex
+	factories map[string]ActionFactory
+}{factories: map[string]ActionFactory{}}
+
+// RegisterAction adds a named action type for use in profiles.
+// Registering a name again replaces the earlier factory.
+func RegisterAction(name string, f ActionFactory) {
+	actionRegistry.mutex.Lock()
+	defer actionRegistry.mutex.Unlock()
+	actionRegistry.factories[name] = f
+}
+
+// ActionTypes returns the names of the registered action types, in
+// order.
+func ActionTypes() []string {
+	actionRegistry.mutex.RLock()
+	defer actionRegistry.mutex.RUnlock()
+	names := make([]string, 0, len(actionRegistry.factories))
+	for name := range actionRegistry.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func actionFactory(name string) ActionFactory {
+	actionRegistry.mutex.RLock()
+	defer actionRegistry.mutex.RUnlock()
+	return actionRegistry.factories[name]
+}
+
+// ActionFeedback holds the colors used to flash the triggering key or
+// button when an action finishes.
+type ActionFeedback struct {
+	Success color.RGBA
+	Failure color.RGBA
+	// Duration is how long the flash lasts.  Zero turns flashes
+	// off.
+	Duration time.Duration
+}
+
+// DefaultActionFeedback is used until SetActionFeedback is called.
+var DefaultActionFeedback = ActionFeedback{
+	Success:  color.RGBA{0, 160, 0, 255},
+	Failure:  color.RGBA{200, 0, 0, 255},
+	Duration: 250 * time.Millisecond,
+}
+
+// actionTable holds the running actions for a Device.
+type actionTable struct {
+	mutex    sync.Mutex
+	running  map[*ActionRun]struct{}
+	feedback *ActionFeedback
+}
+
+// ActionRun is a handle for an action started with RunAction.
+type ActionRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// Cancel asks the action to stop.  It's safe to call after the
+// action has finished.
+func (r *ActionRun) Cancel() {
+	r.cancel()
+}
+
+// Done returns a channel that's closed when the action finishes.
+func (r *ActionRun) Done() <-chan struct{} {
+	return r.done
+}
+
+// Wait waits for the action to finish, and returns its error.
+func (r *ActionRun) Wait() error {
+	<-r.done
+	return r.err
+}
+
+// SetActionFeedback changes the flashes shown when actions finish.
+func (d *Device) SetActionFeedback(f ActionFeedback) {
+	d.actions.mutex.Lock()
+	defer d.actions.mutex.Unlock()
+	d.actions.feedback = &f
+}
+
+// RunAction runs an action in its own goroutine.  When it finishes,
+// the key or button in ac is flashed to show whether it succeeded,
+// and failures are logged.  Keys are only flashed for actions with a
+// Profile, which is used to draw the key again afterwards.
+func (d *Device) RunAction(a Action, ac ActionContext) *ActionRun {
+	ac.Device = d
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &ActionRun{cancel: cancel, done: make(chan struct{})}
+
+	d.actions.mutex.Lock()
+	d.actions.running[r] = struct{}{}
+	d.actions.mutex.Unlock()
+
+	go func() {
+		defer close(r.done)
+		defer cancel()
+
+		panicked := true
+		d.safely(func() {
+			r.err = a.Run(ctx, ac)
+			panicked = false
+		})
+		if panicked {
+			r.err = errors.New("action panicked")
+		}
+
+		d.actions.mutex.Lock()
+		delete(d.actions.running, r)
+		d.actions.mutex.Unlock()
+
+		d.actionDone(a, ac, r.err, ctx.Err() != nil)
+	}()
+	return r
+}
+
+// CancelActions cancels every action that's still running.
+func (d *Device) CancelActions() {
+	d.actions.mutex.Lock()
+	defer d.actions.mutex.Unlock()
+	for r := range d.actions.running {
+		r.cancel()
+	}
+}
+
+// PollAction runs an action straight away, and then again every
+// interval until the returned Binding is unbound, which also cancels
+// any run in progress.  Runs never overlap.  Unlike RunAction,
+// polled runs don't flash their trigger, and failures are only
+// logged at debug level, since polling actions usually show their
+// own results.
+func (d *Device) PollAction(a Action, ac ActionContext, interval time.Duration) *Binding {
+	ac.Device = d
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			var err error
+			d.safely(func() { err = a.Run(ctx, ac) })
+			if err != nil && ctx.Err() == nil {
+				slog.Debug("Polled action failed", "key", ac.Key, "button", ac.Button, "err", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return newBinding(cancel)
+}
+
+// actionFeedback returns the device's ActionFeedback.
+func (d *Device) actionFeedback() ActionFeedback {
+	d.actions.mutex.Lock()
+	defer d.actions.mutex.Unlock()
+	if d.actions.feedback != nil {
+		return *d.actions.feedback
+	}
+	return DefaultActionFeedback
+}
+
+// actionDone logs the result of an action and flashes its trigger.
+func (d *Device) actionDone(a Action, ac ActionContext, err error, cancelled bool) {
+	feedback := d.actionFeedback()
+	c := feedback.Success
+	switch {
+	case cancelled:
+		slog.Info("Action cancelled", "key", ac.Key, "button", ac.Button)
+		return
+	case err != nil:
+		slog.Warn("Action failed", "key", ac.Key, "button", ac.Button, "err", err)
+		c = feedback.Failure
+	}
+	if q, ok := a.(QuietAction); ok && q.Quiet() {
+		return
+	}
+	if feedback.Duration > 0 {
+		d.flash(ac, c, feedback.Duration)
+	}
+}
+
+// flash briefly fills the triggering key with a color, or sets the
+// triggering button's LED to it.
+func (d *Device) flash(ac ActionContext, c color.RGBA, duration time.Duration) {
+	if ac.Key != TouchNone && ac.Profile != nil {
+		if r := d.TouchKeyRect(ac.Key); !r.Empty() {
+			d.DrawTouchKey(ac.Key, solidImage(r.Size(), c))
+			time.AfterFunc(duration, func() { ac.Profile.redrawKey(ac.Key) })
+		}
+	}
+	if b := ac.Button; b >= ButtonCircle && b <= Button7 {
+		old := d.ButtonColor(b)
+		d.setLED(b, c)
+		time.AfterFunc(duration, func() {
+			// Leave the LED alone if something else has set it
+			// in the meantime.
+			if d.ButtonColor(b) == c {
+				d.setLED(b, old)
+			}
+		})
+	}
+}
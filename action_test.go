@@ -0,0 +1,143 @@
+package loupedeck
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellAction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	tests := []struct {
+		name string
+		a    *ShellAction
+		err  string
+	}{
+		{"success", &ShellAction{Command: "true"}, ""},
+		{"failure", &ShellAction{Command: "exit 3"}, "exit status 3"},
+		{"failure with output", &ShellAction{Command: "echo oops; exit 1"}, "exit status 1: oops"},
+		{"timeout", &ShellAction{Command: "sleep 5", Timeout: 50 * time.Millisecond}, "timed out after 50ms"},
+	}
+	for _, tt := range tests {
+		err := tt.a.Run(context.Background(), ActionContext{})
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: Run() = %v", tt.name, err)
+		case tt.err != "" && (err == nil || err.Error() != tt.err):
+			t.Errorf("%s: Run() = %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+// recordAction records that it ran, and fails if err is set.
+type recordAction struct {
+	ran *[]string
+	id  string
+	err error
+}
+
+func (a recordAction) Run(ctx context.Context, ac ActionContext) error {
+	*a.ran = append(*a.ran, a.id)
+	return a.err
+}
+
+func TestSequenceAction(t *testing.T) {
+	var ran []string
+	fail := errors.New("failed")
+	a := &SequenceAction{Steps: []Action{
+		recordAction{&ran, "one", nil},
+		recordAction{&ran, "two", fail},
+		recordAction{&ran, "three", nil},
+	}}
+	err := a.Run(context.Background(), ActionContext{})
+	if !errors.Is(err, fail) || !strings.HasPrefix(err.Error(), "step 2:") {
+		t.Errorf("Run() = %v, want step 2 to fail", err)
+	}
+	if want := "one two"; strings.Join(ran, " ") != want {
+		t.Errorf("ran %v, want %s", ran, want)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ran = nil
+	if err := a.Run(ctx, ActionContext{}); !errors.Is(err, context.Canceled) || len(ran) != 0 {
+		t.Errorf("cancelled Run() = %v after running %v", err, ran)
+	}
+}
+
+func TestSetAction(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	p := NewProfile(d)
+	p.SetValue("v", NewWatched(5.0, 0, 10, 1))
+	ten, minus := 10.0, -3.0
+
+	tests := []struct {
+		name string
+		a    *SetAction
+		want float64
+		err  bool
+	}{
+		{"to", &SetAction{Value: "v", To: &ten}, 10, false},
+		{"by", &SetAction{Value: "v", By: &minus}, 7, false},
+		{"clamped", &SetAction{Value: "v", By: &ten}, 10, false},
+		{"unknown value", &SetAction{Value: "w", To: &ten}, 10, true},
+	}
+	for _, tt := range tests {
+		err := tt.a.Run(context.Background(), ActionContext{Device: d, Profile: p})
+		if (err != nil) != tt.err {
+			t.Errorf("%s: Run() = %v", tt.name, err)
+		}
+		if got := p.Value("v").Get(); got != tt.want {
+			t.Errorf("%s: value is %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRunActionFeedback(t *testing.T) {
+	d := newTestDevice(t, "0004")
+	success, failure := color.RGBA{0, 1, 0, 255}, color.RGBA{1, 0, 0, 255}
+	d.SetActionFeedback(ActionFeedback{Success: success, Failure: failure, Duration: 50 * time.Millisecond})
+	var ran []string
+
+	tests := []struct {
+		name string
+		a    Action
+		want color.RGBA
+	}{
+		{"success", recordAction{&ran, "ok", nil}, success},
+		{"failure", recordAction{&ran, "fail", errors.New("failed")}, failure},
+		{"quiet", &DelayAction{Duration: time.Millisecond}, color.RGBA{}},
+	}
+	for _, tt := range tests {
+		if err := d.RunAction(tt.a, ActionContext{Button: Button2}).Wait(); err != nil && tt.want != failure {
+			t.Errorf("%s: Wait() = %v", tt.name, err)
+		}
+		if got := d.ButtonColor(Button2); got != tt.want {
+			t.Errorf("%s: LED is %v, want %v", tt.name, got, tt.want)
+		}
+		time.Sleep(100 * time.Millisecond)
+		if got := d.ButtonColor(Button2); got != (color.RGBA{}) {
+			t.Errorf("%s: LED is still %v after the flash", tt.name, got)
+		}
+	}
+}
+
+func TestCancelActions(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	r := d.RunAction(&DelayAction{Duration: time.Hour}, ActionContext{})
+	d.RunAction(&CancelAction{}, ActionContext{}).Wait()
+	select {
+	case <-r.Done():
+		if err := r.Wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled action returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("action wasn't cancelled")
+	}
+}
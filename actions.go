@@ -0,0 +1,350 @@
+package loupedeck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// The built-in action types, by the names used in profiles.
+func init() {
+	RegisterAction("shell", func(decode func(any) error) (Action, error) {
+		a := &ShellAction{}
+		if err := decode(a); err != nil {
+			return nil, err
+		}
+		if a.Command == "" {
+			return nil, errors.New("shell action needs a command")
+		}
+		return a, nil
+	})
+	RegisterAction("http", func(decode func(any) error) (Action, error) {
+		a := &HTTPAction{}
+		if err := decode(a); err != nil {
+			return nil, err
+		}
+		if a.URL == "" {
+			return nil, errors.New("http action needs a url")
+		}
+		return a, nil
+	})
+	for name, op := range pageOps {
+		op := op
+		RegisterAction(name, func(decode func(any) error) (Action, error) {
+			a := &PageAction{Op: op}
+			if err := decode(a); err != nil {
+				return nil, err
+			}
+			needsPage := op == PageShow || op == PagePush
+			switch {
+			case needsPage && a.Page == "":
+				return nil, fmt.Errorf("%s action needs a page", op)
+			case !needsPage && a.Page != "":
+				return nil, fmt.Errorf("%s action doesn't take a page", op)
+			}
+			return a, nil
+		})
+	}
+	RegisterAction("set", func(decode func(any) error) (Action, error) {
+		a := &SetAction{}
+		if err := decode(a); err != nil {
+			return nil, err
+		}
+		switch {
+		case a.Value == "":
+			return nil, errors.New("set action needs a value")
+		case (a.To == nil) == (a.By == nil):
+			return nil, errors.New("set action needs one of to or by")
+		}
+		return a, nil
+	})
+	RegisterAction("sequence", func(decode func(any) error) (Action, error) {
+		var params struct {
+			Steps []*actionConfig `yaml:"steps"`
+		}
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		if len(params.Steps) == 0 {
+			return nil, errors.New("sequence action needs steps")
+		}
+		a := &SequenceAction{}
+		for _, step := range params.Steps {
+			if step.err != nil {
+				return nil, step.err
+			}
+			a.Steps = append(a.Steps, step.action)
+		}
+		return a, nil
+	})
+	RegisterAction("delay", func(decode func(any) error) (Action, error) {
+		a := &DelayAction{}
+		if err := decode(a); err != nil {
+			return nil, err
+		}
+		if a.Duration <= 0 {
+			return nil, errors.New("delay action needs a positive duration")
+		}
+		return a, nil
+	})
+	RegisterAction("cancel", func(decode func(any) error) (Action, error) {
+		a := &CancelAction{}
+		return a, decode(a)
+	})
+}
+
+// ShellAction runs a command with the system shell.  It fails if the
+// command exits with an error.
+type ShellAction struct {
+	Command string `yaml:"command"`
+	// Timeout stops the command if it runs for too long.  Zero
+	// means no timeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Run implements Action.
+func (a *ShellAction) Run(ctx context.Context, ac ActionContext) error {
+	if a.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.Timeout)
+		defer cancel()
+	}
+
+	cmd := shellCommand(ctx, a.Command)
+	slog.Info("Running command", "command", a.Command)
+	out, err := cmd.CombinedOutput()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v", a.Timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// shellCommand returns a command that runs s with the system shell,
+// and is stopped when ctx is cancelled.
+func shellCommand(ctx context.Context, s string) *exec.Cmd {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s)
+	}
+	// Don't wait for the command's children to close its output
+	// after it's been stopped.
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// DefaultHTTPTimeout is used for HTTPActions without a Timeout.
+const DefaultHTTPTimeout = 10 * time.Second
+
+// HTTPAction sends an HTTP request, such as to a local service's API.
+// It fails unless the response has a 2xx status.
+type HTTPAction struct {
+	URL string `yaml:"url"`
+	// Method defaults to POST if there's a Body, and GET
+	// otherwise.
+	Method  string            `yaml:"method"`
+	Body    string            `yaml:"body"`
+	Headers map[string]string `yaml:"headers"`
+	// Timeout defaults to DefaultHTTPTimeout.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Run implements Action.
+func (a *HTTPAction) Run(ctx context.Context, ac ActionContext) error {
+	timeout := a.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	method := a.Method
+	switch {
+	case method != "":
+	case a.Body != "":
+		method = http.MethodPost
+	default:
+		method = http.MethodGet
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.URL, strings.NewReader(a.Body))
+	if err != nil {
+		return err
+	}
+	for k, v := range a.Headers {
+		req.Header.Set(k, v)
+	}
+
+	slog.Info("Sending HTTP request", "method", method, "url", a.URL)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s", method, a.URL, resp.Status)
+	}
+	return nil
+}
+
+// PageOp is the kind of page switch done by a PageAction.
+type PageOp uint8
+
+const (
+	PageShow     PageOp = 0
+	PagePush     PageOp = 1
+	PageBack     PageOp = 2
+	PageNext     PageOp = 3
+	PagePrevious PageOp = 4
+)
+
+// pageOps are the names of the page action types.
+var pageOps = map[string]PageOp{
+	"show":     PageShow,
+	"push":     PagePush,
+	"back":     PageBack,
+	"next":     PageNext,
+	"previous": PagePrevious,
+}
+
+func (op PageOp) String() string {
+	for name, o := range pageOps {
+		if o == op {
+			return name
+		}
+	}
+	return fmt.Sprintf("PageOp(%d)", op)
+}
+
+// PageAction switches pages in the action's Profile.  Page is used
+// by PageShow and PagePush.
+type PageAction struct {
+	Op   PageOp `yaml:"-"`
+	Page string `yaml:"page"`
+}
+
+// Run implements Action.
+func (a *PageAction) Run(ctx context.Context, ac ActionContext) error {
+	p := ac.Profile
+	if p == nil {
+		return errors.New("page action needs a profile")
+	}
+	switch a.Op {
+	case PageShow:
+		return p.Show(a.Page)
+	case PagePush:
+		return p.Push(a.Page)
+	case PageBack:
+		p.Back()
+	case PageNext:
+		p.Next()
+	case PagePrevious:
+		p.Previous()
+	}
+	return nil
+}
+
+// Quiet implements QuietAction.
+func (a *PageAction) Quiet() bool {
+	return true
+}
+
+// SetAction changes one of the Profile's values, either to a new
+// value or by an amount.
+type SetAction struct {
+	Value string   `yaml:"value"`
+	To    *float64 `yaml:"to"`
+	By    *float64 `yaml:"by"`
+}
+
+// Run implements Action.
+func (a *SetAction) Run(ctx context.Context, ac ActionContext) error {
+	if ac.Profile == nil {
+		return errors.New("set action needs a profile")
+	}
+	w := ac.Profile.Value(a.Value)
+	if w == nil {
+		return fmt.Errorf("no value named %q", a.Value)
+	}
+	if a.To != nil {
+		w.Set(*a.To)
+	} else {
+		w.Set(w.Get() + *a.By)
+	}
+	return nil
+}
+
+// Quiet implements QuietAction.
+func (a *SetAction) Quiet() bool {
+	return true
+}
+
+// SequenceAction runs actions one after another, stopping at the
+// first one that fails.  DelayActions can be used to wait between
+// steps.
+type SequenceAction struct {
+	Steps []Action
+}
+
+// Run implements Action.
+func (a *SequenceAction) Run(ctx context.Context, ac ActionContext) error {
+	for i, step := range a.Steps {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := step.Run(ctx, ac); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// DelayAction waits, usually between the steps of a SequenceAction.
+type DelayAction struct {
+	Duration time.Duration `yaml:"duration"`
+}
+
+// Run implements Action.
+func (a *DelayAction) Run(ctx context.Context, ac ActionContext) error {
+	t := time.NewTimer(a.Duration)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Quiet implements QuietAction.
+func (a *DelayAction) Quiet() bool {
+	return true
+}
+
+// CancelAction cancels all of the device's running actions.
+type CancelAction struct{}
+
+// Run implements Action.
+func (a *CancelAction) Run(ctx context.Context, ac ActionContext) error {
+	ac.Device.CancelActions()
+	return nil
+}
+
+// Quiet implements QuietAction.
+func (a *CancelAction) Quiet() bool {
+	return true
+}
@@ -0,0 +1,140 @@
+package loupedeck
+
+import (
+	"sync"
+)
+
+// Binding is a handle for a callback registered with one of the
+// Bind functions.  Calling Unbind removes the callback; other
+// callbacks bound to the same control are unaffected.
+type Binding struct {
+	once   sync.Once
+	unbind func()
+}
+
+func newBinding(unbind func()) *Binding {
+	return &Binding{unbind: unbind}
+}
+
+// joinBindings returns a single Binding that unbinds all of bs.
+func joinBindings(bs ...*Binding) *Binding {
+	return newBinding(func() {
+		for _, b := range bs {
+			b.Unbind()
+		}
+	})
+}
+
+// Unbind removes the callback.  It's safe to call more than once,
+// and on a nil Binding.
+func (b *Binding) Unbind() {
+	if b == nil {
+		return
+	}
+	b.once.Do(func() {
+		if b.unbind != nil {
+			b.unbind()
+		}
+	})
+}
+
+// handler is a single registered callback.
+type handler[F any] struct {
+	id int
+	f  F
+}
+
+// handlerList holds the callbacks for one kind of event, keyed by
+// control, along with wildcard callbacks that see every control.
+// It's safe for concurrent use, so callbacks may be bound and
+// unbound from any goroutine, including from inside a callback.
+type handlerList[K comparable, F any] struct {
+	mutex  sync.RWMutex
+	nextID int
+	byKey  map[K][]handler[F]
+	any    []handler[F]
+}
+
+// add registers a callback for a single control.
+func (h *handlerList[K, F]) add(k K, f F) *Binding {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.byKey == nil {
+		h.byKey = map[K][]handler[F]{}
+	}
+	h.nextID++
+	id := h.nextID
+	h.byKey[k] = append(h.byKey[k], handler[F]{id: id, f: f})
+
+	return newBinding(func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		h.byKey[k] = removeHandler(h.byKey[k], id)
+		if len(h.byKey[k]) == 0 {
+			delete(h.byKey, k)
+		}
+	})
+}
+
+// addAny registers a wildcard callback.
+func (h *handlerList[K, F]) addAny(f F) *Binding {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.nextID++
+	id := h.nextID
+	h.any = append(h.any, handler[F]{id: id, f: f})
+
+	return newBinding(func() {
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		h.any = removeHandler(h.any, id)
+	})
+}
+
+// get returns the callbacks for a control, in the order they were
+// bound, followed by the wildcard callbacks.  The returned slice is
+// a copy, so callbacks may bind and unbind while it's in use.
+func (h *handlerList[K, F]) get(k K) []F {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	fs := make([]F, 0, len(h.byKey[k])+len(h.any))
+	for _, x := range h.byKey[k] {
+		fs = append(fs, x.f)
+	}
+	for _, x := range h.any {
+		fs = append(fs, x.f)
+	}
+	return fs
+}
+
+// has returns true if any callback would be returned by get(k).
+func (h *handlerList[K, F]) has(k K) bool {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.byKey[k]) > 0 || len(h.any) > 0
+}
+
+func removeHandler[F any](hs []handler[F], id int) []handler[F] {
+	for i, x := range hs {
+		if x.id == id {
+			return append(hs[:i:i], hs[i+1:]...)
+		}
+	}
+	return hs
+}
+
+// bindingTable holds all of the callbacks for a Device.
+type bindingTable struct {
+	button       handlerList[Button, ButtonFunc]
+	buttonUp     handlerList[Button, ButtonFunc]
+	knob         handlerList[Knob, KnobFunc]
+	touch        handlerList[TouchButton, TouchFunc]
+	touchUp      handlerList[TouchButton, TouchFunc]
+	touchMove    handlerList[TouchButton, TouchMoveFunc]
+	touchSession handlerList[struct{}, TouchSessionFunc]
+	wheel        handlerList[struct{}, WheelFunc]
+	wheelTouch   handlerList[struct{}, WheelTouchFunc]
+}
@@ -0,0 +1,68 @@
+package loupedeck
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestHandlerList(t *testing.T) {
+	var h handlerList[Button, string]
+	a := h.add(Button1, "a")
+	h.add(Button1, "b")
+	any1 := h.addAny("any")
+	h.add(Button2, "c")
+	c := h.add(Button1, "d")
+
+	tests := []struct {
+		name   string
+		unbind *Binding
+		b      Button
+		want   []string
+	}{
+		{"in order, then wildcards", nil, Button1, []string{"a", "b", "d", "any"}},
+		{"other control", nil, Button2, []string{"c", "any"}},
+		{"no callbacks of its own", nil, Button3, []string{"any"}},
+		{"unbind one", a, Button1, []string{"b", "d", "any"}},
+		{"unbind twice", a, Button1, []string{"b", "d", "any"}},
+		{"unbind a wildcard", any1, Button2, []string{"c"}},
+		{"unbind the last one", c, Button1, []string{"b"}},
+		{"nothing left", nil, Button3, []string{}},
+	}
+	for _, tt := range tests {
+		tt.unbind.Unbind()
+		if got := h.get(tt.b); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: get(%v) = %v, want %v", tt.name, tt.b, got, tt.want)
+		}
+		if got := h.has(tt.b); got != (len(tt.want) > 0) {
+			t.Errorf("%s: has(%v) = %v", tt.name, tt.b, got)
+		}
+	}
+}
+
+func TestUnbindFromCallback(t *testing.T) {
+	var h handlerList[Button, func()]
+	calls := 0
+	var b *Binding
+	b = h.add(Button1, func() {
+		calls++
+		b.Unbind()
+	})
+	for i := 0; i < 2; i++ {
+		for _, f := range h.get(Button1) {
+			f()
+		}
+	}
+	if calls != 1 {
+		t.Errorf("callback ran %d times, want 1", calls)
+	}
+}
+
+func TestJoinBindings(t *testing.T) {
+	n := 0
+	b := joinBindings(newBinding(func() { n++ }), nil, newBinding(func() { n++ }))
+	b.Unbind()
+	b.Unbind()
+	if n != 2 {
+		t.Errorf("%d bindings were unbound, want 2", n)
+	}
+}
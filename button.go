@@ -56,16 +56,30 @@ const (
 // current state is.
 type ButtonFunc func(Button, ButtonState)
 
-// BindButton sets a callback for actions on a specific
+// BindButton adds a callback for actions on a specific
 // button.  When the Button is pushed down, then the provided
-// ButtonFunc is called.
-func (d *Device) BindButton(b Button, f ButtonFunc) {
-	d.buttonBindings[b] = f
+// ButtonFunc is called.  Any number of callbacks may be bound to the
+// same Button; they're called in the order they were bound.  The
+// returned Binding may be used to remove the callback.
+func (d *Device) BindButton(b Button, f ButtonFunc) *Binding {
+	return d.bindings.button.add(b, f)
 }
 
-// BindButtonUp sets a callback for actions on a specific
+// BindButtonUp adds a callback for actions on a specific
 // button.  When the Button is released, then the provided
 // ButtonFunc is called.
-func (d *Device) BindButtonUp(b Button, f ButtonFunc) {
-	d.buttonUpBindings[b] = f
+func (d *Device) BindButtonUp(b Button, f ButtonFunc) *Binding {
+	return d.bindings.buttonUp.add(b, f)
+}
+
+// BindAnyButton adds a callback that is called when any Button is
+// pushed down, after the callbacks for that specific Button.
+func (d *Device) BindAnyButton(f ButtonFunc) *Binding {
+	return d.bindings.button.addAny(f)
+}
+
+// BindAnyButtonUp adds a callback that is called when any Button is
+// released.
+func (d *Device) BindAnyButtonUp(f ButtonFunc) *Binding {
+	return d.bindings.buttonUp.addAny(f)
 }
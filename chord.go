@@ -0,0 +1,360 @@
+package loupedeck
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// ChordFunc is a function signature used for callbacks on chords.
+// It's called with the buttons that make up the chord.
+type ChordFunc func([]Button)
+
+// Layer is a set of bindings that replaces the normal ones while a
+// modifier button is held, like a "shift" key.  Controls that have
+// no binding on the layer fall through to their normal bindings.
+type Layer struct {
+	device   *Device
+	Button   Button
+	bindings bindingTable
+}
+
+// BindButton adds a callback for a button press while the layer's
+// modifier is held.
+func (l *Layer) BindButton(b Button, f ButtonFunc) *Binding {
+	return l.bindings.button.add(b, f)
+}
+
+// BindButtonUp adds a callback for a button release while the
+// layer's modifier is held.
+func (l *Layer) BindButtonUp(b Button, f ButtonFunc) *Binding {
+	return l.bindings.buttonUp.add(b, f)
+}
+
+// BindKnob adds a callback for knob turns while the layer's
+// modifier is held.
+func (l *Layer) BindKnob(k Knob, f KnobFunc) *Binding {
+	return l.bindings.knob.add(k, f)
+}
+
+// BindTouch adds a callback for TouchButton presses while the
+// layer's modifier is held.
+func (l *Layer) BindTouch(b TouchButton, f TouchFunc) *Binding {
+	return l.bindings.touch.add(b, f)
+}
+
+// BindTouchUp adds a callback for TouchButton releases while the
+// layer's modifier is held.
+func (l *Layer) BindTouchUp(b TouchButton, f TouchFunc) *Binding {
+	return l.bindings.touchUp.add(b, f)
+}
+
+// noButton is used where a Button is needed but none applies.
+const noButton Button = 0xffff
+
+// chord is a single registered chord.
+type chord struct {
+	buttons []Button
+	f       ChordFunc
+	fired   bool
+}
+
+// heldKnob identifies a knob binding that's active while a button
+// is held.
+type heldKnob struct {
+	button Button
+	knob   Knob
+}
+
+// heldButton tracks a button that is currently down.
+type heldButton struct {
+	// trigger is set for buttons that are part of a chord,
+	// modifier, or held-knob binding.  Their own press is
+	// delayed until release, and dropped if they were used.
+	trigger bool
+	used    bool
+	// table is the binding table that the press was routed to.
+	table *bindingTable
+}
+
+// chordState holds the chord and modifier bindings for a Device,
+// along with the buttons currently held.
+type chordState struct {
+	mutex     sync.Mutex
+	chords    []*chord
+	modifiers map[Button]*Layer
+	heldKnobs handlerList[heldKnob, KnobFunc]
+	triggers  map[Button]int
+	held      map[Button]*heldButton
+	// order lists the held buttons, oldest first.
+	order []Button
+	// touchTables holds the binding table that each finger's
+	// touch was routed to, so that its release goes to the same
+	// one.
+	touchTables map[touchKey]*bindingTable
+}
+
+// BindChord adds a callback for a chord: all of the given buttons
+// held down at the same time, in any order.  The callback is called
+// once, when the last button of the chord is pressed.
+//
+// Buttons that are part of a chord no longer send their own
+// ButtonDown when pressed.  Instead, if the button is released
+// without completing a chord, its ButtonDown and ButtonUp callbacks
+// are both called on release.  If it was used in a chord, neither
+// is called.
+func (d *Device) BindChord(f ChordFunc, buttons ...Button) *Binding {
+	c := &d.chords
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ch := &chord{buttons: append([]Button{}, buttons...), f: f}
+	c.chords = append(c.chords, ch)
+	c.addTriggers(buttons...)
+
+	return newBinding(func() {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for i, x := range c.chords {
+			if x == ch {
+				c.chords = append(c.chords[:i:i], c.chords[i+1:]...)
+				break
+			}
+		}
+		c.removeTriggers(buttons...)
+	})
+}
+
+// BindKnobWhileHeld adds a callback for a knob while a button is
+// held, such as a fine-adjust mode.  While the button is held, turns
+// of the knob go to this callback instead of the knob's normal
+// bindings.  Like chords, the button's own press is delayed until
+// release and dropped if the knob was turned.
+func (d *Device) BindKnobWhileHeld(b Button, k Knob, f KnobFunc) *Binding {
+	c := &d.chords
+	c.mutex.Lock()
+	c.addTriggers(b)
+	c.mutex.Unlock()
+
+	binding := c.heldKnobs.add(heldKnob{b, k}, f)
+	return newBinding(func() {
+		binding.Unbind()
+		c.mutex.Lock()
+		c.removeTriggers(b)
+		c.mutex.Unlock()
+	})
+}
+
+// AddModifier makes a button into a modifier, like a "shift" key,
+// and returns the Layer of bindings that are used while it's held.
+// Calling AddModifier again for the same button returns the same
+// Layer.  Like chords, the modifier's own press is delayed until
+// release and dropped if any other control was used while it was
+// held.
+func (d *Device) AddModifier(b Button) *Layer {
+	c := &d.chords
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if l := c.modifiers[b]; l != nil {
+		return l
+	}
+	l := &Layer{device: d, Button: b}
+	c.modifiers[b] = l
+	c.addTriggers(b)
+	return l
+}
+
+// RemoveModifier turns a modifier button back into a normal button.
+func (d *Device) RemoveModifier(b Button) {
+	c := &d.chords
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.modifiers[b] != nil {
+		delete(c.modifiers, b)
+		c.removeTriggers(b)
+	}
+}
+
+func (c *chordState) addTriggers(buttons ...Button) {
+	for _, b := range buttons {
+		c.triggers[b]++
+	}
+}
+
+func (c *chordState) removeTriggers(buttons ...Button) {
+	for _, b := range buttons {
+		c.triggers[b]--
+		if c.triggers[b] <= 0 {
+			delete(c.triggers, b)
+		}
+	}
+}
+
+// activeLayer returns the layer for the most recently pressed
+// modifier that is still held, ignoring the button except, and
+// marks that modifier as used.  The caller must hold the mutex.
+func (c *chordState) activeLayer(except Button) *Layer {
+	for i := len(c.order) - 1; i >= 0; i-- {
+		b := c.order[i]
+		if b == except {
+			continue
+		}
+		if l := c.modifiers[b]; l != nil {
+			c.held[b].used = true
+			return l
+		}
+	}
+	return nil
+}
+
+// handleButton applies chords and modifiers to a button press or
+// release, and then passes it on to pressButton for long-press,
+// double-press, and auto-repeat handling.
+func (d *Device) handleButton(b Button, state ButtonState) {
+	c := &d.chords
+	c.mutex.Lock()
+
+	if state == ButtonDown {
+		if c.held[b] != nil {
+			// Already down; the device doesn't send repeats,
+			// but be safe.
+			c.mutex.Unlock()
+			return
+		}
+		h := &heldButton{trigger: c.triggers[b] > 0, table: &d.bindings}
+		if l := c.activeLayer(b); l != nil && (l.bindings.button.has(b) || l.bindings.buttonUp.has(b)) {
+			h.table = &l.bindings
+		}
+		c.held[b] = h
+		c.order = append(c.order, b)
+
+		var fired []*chord
+		for _, ch := range c.chords {
+			if !ch.fired && ch.contains(b) && c.allHeld(ch.buttons) {
+				ch.fired = true
+				fired = append(fired, ch)
+				for _, m := range ch.buttons {
+					c.held[m].used = true
+				}
+			}
+		}
+		c.mutex.Unlock()
+
+		for _, ch := range fired {
+			ch := ch
+			slog.Info("Chord", "buttons", ch.buttons)
+			d.run(controlKey{buttonControl, uint32(b)}, func() { ch.f(ch.buttons) })
+		}
+		if !h.trigger {
+			d.pressButton(h.table, b, ButtonDown)
+		}
+		return
+	}
+
+	h := c.held[b]
+	delete(c.held, b)
+	for i, x := range c.order {
+		if x == b {
+			c.order = append(c.order[:i:i], c.order[i+1:]...)
+			break
+		}
+	}
+	for _, ch := range c.chords {
+		if ch.contains(b) {
+			ch.fired = false
+		}
+	}
+	c.mutex.Unlock()
+
+	if h == nil {
+		d.pressButton(&d.bindings, b, ButtonUp)
+		return
+	}
+	if h.trigger {
+		if h.used {
+			return
+		}
+		d.pressButton(h.table, b, ButtonDown)
+	}
+	d.pressButton(h.table, b, ButtonUp)
+}
+
+// handleKnob applies held-knob bindings and modifiers to a knob
+// turn, and then calls the appropriate callbacks.
+func (d *Device) handleKnob(k Knob, v int) {
+	for _, f := range d.knobCallbacks(k, v) {
+		d.run(controlKey{knobControl, uint32(k)}, f)
+	}
+}
+
+// knobCallbacks returns the callbacks for a knob turn, after
+// applying held-knob bindings and modifiers.
+func (d *Device) knobCallbacks(k Knob, v int) []func() {
+	c := &d.chords
+	c.mutex.Lock()
+
+	for i := len(c.order) - 1; i >= 0; i-- {
+		b := c.order[i]
+		if fs := c.heldKnobs.get(heldKnob{b, k}); len(fs) > 0 {
+			c.held[b].used = true
+			c.mutex.Unlock()
+			return knobCalls(fs, k, v)
+		}
+	}
+
+	table := &d.bindings
+	if l := c.activeLayer(noButton); l != nil && l.bindings.knob.has(k) {
+		table = &l.bindings
+	}
+	c.mutex.Unlock()
+
+	calls := knobCalls(table.knob.get(k), k, v)
+	if k == CTKnob {
+		calls = append(calls, d.wheelCalls(v)...)
+	}
+	return calls
+}
+
+// handleTouch applies modifiers to a TouchButton press or release
+// by the finger with the given ID, and then calls the appropriate
+// callbacks.  Releases go to the same layer as the press, even if the
+// modifier has been released or the finger has moved to another key.
+func (d *Device) handleTouch(id byte, b TouchButton, state ButtonState, x, y uint16) {
+	c := &d.chords
+	key := touchKeyFor(id, b)
+	c.mutex.Lock()
+	table := &d.bindings
+	if state == ButtonDown {
+		if l := c.activeLayer(noButton); l != nil && (l.bindings.touch.has(b) || l.bindings.touchUp.has(b)) {
+			table = &l.bindings
+		}
+		c.touchTables[key] = table
+	} else if t := c.touchTables[key]; t != nil {
+		table = t
+		delete(c.touchTables, key)
+	}
+	c.mutex.Unlock()
+
+	d.dispatchTouch(table, b, state, x, y)
+}
+
+func (ch *chord) contains(b Button) bool {
+	for _, x := range ch.buttons {
+		if x == b {
+			return true
+		}
+	}
+	return false
+}
+
+// allHeld returns true if every button is held.  The caller must
+// hold the mutex.
+func (c *chordState) allHeld(buttons []Button) bool {
+	for _, b := range buttons {
+		if c.held[b] == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package loupedeck
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// input is a single button or knob event in a chord test.  Knob
+// events have a non-zero delta.
+type input struct {
+	b     Button
+	state ButtonState
+	k     Knob
+	delta int
+}
+
+func down(b Button) input          { return input{b: b, state: ButtonDown} }
+func up(b Button) input            { return input{b: b, state: ButtonUp} }
+func turn(k Knob, delta int) input { return input{k: k, delta: delta} }
+
+func TestChords(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []input
+		want   []string
+	}{
+		{
+			name:   "plain press",
+			inputs: []input{down(Button3), up(Button3)},
+			want:   []string{"down 3", "up 3"},
+		},
+		{
+			name:   "chord",
+			inputs: []input{down(Button1), down(Button2), up(Button1), up(Button2)},
+			want:   []string{"chord"},
+		},
+		{
+			name:   "chord in the other order",
+			inputs: []input{down(Button2), down(Button1), up(Button2), up(Button1)},
+			want:   []string{"chord"},
+		},
+		{
+			name:   "chord button alone is delayed until release",
+			inputs: []input{down(Button1), up(Button1)},
+			want:   []string{"down 1", "up 1"},
+		},
+		{
+			name:   "chord fires again when completed again",
+			inputs: []input{down(Button1), down(Button2), up(Button2), down(Button2), up(Button2), up(Button1)},
+			want:   []string{"chord", "chord"},
+		},
+		{
+			name:   "knob turned normally",
+			inputs: []input{turn(Knob1, 2)},
+			want:   []string{"knob 2"},
+		},
+		{
+			name:   "knob while held",
+			inputs: []input{down(Button4), turn(Knob1, 2), up(Button4), turn(Knob1, 1)},
+			want:   []string{"fine 2", "knob 1"},
+		},
+		{
+			name:   "held button without a turn",
+			inputs: []input{down(Button4), up(Button4)},
+			want:   []string{"down 4", "up 4"},
+		},
+		{
+			name:   "modifier layer",
+			inputs: []input{down(Button5), down(Button3), up(Button3), turn(Knob2, 1), up(Button5)},
+			want:   []string{"shift down 3", "shift up 3", "shift knob 1"},
+		},
+		{
+			name:   "release after the modifier goes to the same layer",
+			inputs: []input{down(Button5), down(Button3), up(Button5), up(Button3)},
+			want:   []string{"shift down 3", "shift up 3"},
+		},
+		{
+			name:   "modifier alone",
+			inputs: []input{down(Button5), up(Button5)},
+			want:   []string{"down 5", "up 5"},
+		},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		var got []string
+		log := func(format string, args ...any) { got = append(got, fmt.Sprintf(format, args...)) }
+		button := func(b Button, s ButtonState) {
+			if s == ButtonDown {
+				log("down %d", b-Button0)
+			} else {
+				log("up %d", b-Button0)
+			}
+		}
+		d.BindAnyButton(button)
+		d.BindAnyButtonUp(button)
+		d.BindKnob(Knob1, func(_ Knob, v int) { log("knob %d", v) })
+		d.BindChord(func([]Button) { log("chord") }, Button1, Button2)
+		d.BindKnobWhileHeld(Button4, Knob1, func(_ Knob, v int) { log("fine %d", v) })
+		shift := d.AddModifier(Button5)
+		shift.BindButton(Button3, func(b Button, _ ButtonState) { log("shift down %d", b-Button0) })
+		shift.BindButtonUp(Button3, func(b Button, _ ButtonState) { log("shift up %d", b-Button0) })
+		shift.BindKnob(Knob2, func(_ Knob, v int) { log("shift knob %d", v) })
+
+		for _, in := range tt.inputs {
+			if in.delta != 0 {
+				d.handleKnob(in.k, in.delta)
+			} else {
+				d.handleButton(in.b, in.state)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestModifierTouches(t *testing.T) {
+	// touch is a finger pressing or releasing a TouchButton, or,
+	// with b unset, the modifier being pressed or released.
+	type touch struct {
+		id    byte
+		b     TouchButton
+		state ButtonState
+	}
+	shiftDown := touch{state: ButtonDown}
+	shiftUp := touch{state: ButtonUp}
+	tests := []struct {
+		name    string
+		touches []touch
+		want    []string
+	}{
+		{
+			name:    "no modifier",
+			touches: []touch{{1, Touch1, ButtonDown}, {1, Touch1, ButtonUp}},
+			want:    []string{"down 1", "up 1"},
+		},
+		{
+			name:    "modifier",
+			touches: []touch{shiftDown, {1, Touch1, ButtonDown}, {1, Touch1, ButtonUp}, shiftUp},
+			want:    []string{"shift down 1", "shift up 1"},
+		},
+		{
+			name:    "released on another key",
+			touches: []touch{shiftDown, {1, Touch1, ButtonDown}, {1, Touch2, ButtonUp}, shiftUp},
+			want:    []string{"shift down 1", "shift up 2"},
+		},
+		{
+			name:    "released after the modifier",
+			touches: []touch{shiftDown, {1, Touch1, ButtonDown}, shiftUp, {1, Touch2, ButtonUp}},
+			want:    []string{"shift down 1", "shift up 2"},
+		},
+		{
+			name:    "two fingers",
+			touches: []touch{{1, Touch1, ButtonDown}, shiftDown, {2, Touch1, ButtonDown}, {1, Touch1, ButtonUp}, {2, Touch2, ButtonUp}, shiftUp},
+			want:    []string{"down 1", "shift down 1", "up 1", "shift up 2"},
+		},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		var got []string
+		log := func(prefix string) TouchFunc {
+			return func(b TouchButton, _ ButtonState, _, _ uint16) {
+				got = append(got, fmt.Sprintf("%s %d", prefix, b))
+			}
+		}
+		shift := d.AddModifier(Button5)
+		for _, b := range []TouchButton{Touch1, Touch2} {
+			d.BindTouch(b, log("down"))
+			d.BindTouchUp(b, log("up"))
+			shift.BindTouch(b, log("shift down"))
+			shift.BindTouchUp(b, log("shift up"))
+		}
+
+		for _, in := range tt.touches {
+			if in.b == 0 {
+				d.handleButton(Button5, in.state)
+			} else {
+				d.handleTouch(in.id, in.b, in.state, 0, 0)
+			}
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if n := len(d.chords.touchTables); n != 0 {
+			t.Errorf("%s: %d touches still recorded after release", tt.name, n)
+		}
+	}
+}
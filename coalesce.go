@@ -0,0 +1,93 @@
+package loupedeck
+
+import (
+	"time"
+)
+
+// CoalesceUntilDone can be passed to SetKnobCoalescing in place of a
+// window, to deliver turns as soon as the previous callback returns.
+const CoalesceUntilDone time.Duration = -1
+
+// SetKnobCoalescing turns on coalescing for a knob.  Instead of
+// calling the knob's callbacks once for every tick, ticks are added
+// up and delivered as a single delta, which keeps callbacks that
+// redraw a display from falling behind a fast-spinning knob.  The
+// total movement is unchanged.
+//
+// With a positive window, the first tick starts a timer and every
+// tick that arrives before it expires is added in.  With
+// CoalesceUntilDone, the first tick is delivered immediately and
+// ticks that arrive while the callbacks are running are delivered
+// together once they return.  Either way, a knob's callbacks are
+// never called again until the previous call has returned.  A window
+// of 0 turns coalescing off.
+//
+// Coalescing only affects callbacks; every tick is still sent on the
+// Events channel.
+func (d *Device) SetKnobCoalescing(k Knob, window time.Duration) {
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+	s := d.knobState(k)
+	s.coalesce = window != 0
+	s.window = window
+}
+
+// coalesceKnob passes a knob turn on to the knob's callbacks,
+// coalescing it with other turns if that's turned on.
+func (d *Device) coalesceKnob(k Knob, v int) {
+	d.knobMutex.Lock()
+	s := d.knobState(k)
+	if !s.coalesce && !s.busy && s.pending == 0 {
+		d.knobMutex.Unlock()
+		d.handleKnob(k, v)
+		return
+	}
+
+	s.pending += v
+	if !s.busy && s.timer == nil {
+		d.scheduleKnob(k, s)
+	}
+	d.knobMutex.Unlock()
+}
+
+// scheduleKnob arranges for the knob's pending turns to be
+// delivered.  The caller must hold knobMutex.
+func (d *Device) scheduleKnob(k Knob, s *knobState) {
+	if s.window > 0 {
+		s.timer = time.AfterFunc(s.window, func() { d.flushKnob(k) })
+		return
+	}
+	s.busy = true
+	go d.flushKnob(k)
+}
+
+// flushKnob delivers a knob's pending turns, waits for its callbacks
+// to return, and then schedules any turns that arrived meanwhile.
+func (d *Device) flushKnob(k Knob) {
+	d.knobMutex.Lock()
+	s := d.knobState(k)
+	s.timer = nil
+	v := s.pending
+	s.pending = 0
+	s.busy = true
+	d.knobMutex.Unlock()
+
+	if v != 0 {
+		calls := d.knobCallbacks(k, v)
+		done := make(chan struct{})
+		d.run(controlKey{knobControl, uint32(k)}, func() {
+			defer close(done)
+			for _, f := range calls {
+				d.safely(f)
+			}
+		})
+		<-done
+	}
+
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+	s.busy = false
+	if s.pending != 0 {
+		d.scheduleKnob(k, s)
+	}
+}
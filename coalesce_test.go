@@ -0,0 +1,51 @@
+package loupedeck
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKnobCoalescing(t *testing.T) {
+	tests := []struct {
+		name   string
+		window time.Duration
+		// busy is how long each callback takes.
+		busy time.Duration
+		// pause is how long to wait after the first tick.
+		pause time.Duration
+		want  []int
+	}{
+		{"off", 0, 0, 0, []int{1, 1, 1, 1, 1}},
+		{"window", 50 * time.Millisecond, 0, 0, []int{5}},
+		{"window with a pause", 50 * time.Millisecond, 0, 100 * time.Millisecond, []int{1, 4}},
+		{"until done", CoalesceUntilDone, 50 * time.Millisecond, 10 * time.Millisecond, []int{1, 4}},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		d.SetKnobCoalescing(Knob1, tt.window)
+		var mutex sync.Mutex
+		var got []int
+		busy := tt.busy
+		d.BindKnob(Knob1, func(_ Knob, v int) {
+			mutex.Lock()
+			got = append(got, v)
+			mutex.Unlock()
+			time.Sleep(busy)
+		})
+
+		d.coalesceKnob(Knob1, 1)
+		time.Sleep(tt.pause)
+		for i := 0; i < 4; i++ {
+			d.coalesceKnob(Knob1, 1)
+		}
+		time.Sleep(200 * time.Millisecond)
+
+		mutex.Lock()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: callbacks got %v, want %v", tt.name, got, tt.want)
+		}
+		mutex.Unlock()
+	}
+}
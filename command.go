@@ -0,0 +1,220 @@
+package loupedeck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterAction("command", func(decode func(any) error) (Action, error) {
+		var params struct {
+			Command string            `yaml:"command"`
+			Args    []string          `yaml:"args"`
+			Env     map[string]string `yaml:"env"`
+			Dir     string            `yaml:"dir"`
+			Timeout time.Duration     `yaml:"timeout"`
+			Poll    time.Duration     `yaml:"poll"`
+			Colors  map[int]string    `yaml:"colors"`
+		}
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+		if params.Command == "" {
+			return nil, errors.New("command action needs a command")
+		}
+		if params.Poll < 0 {
+			return nil, errors.New("command action's poll interval can't be negative")
+		}
+
+		a := &CommandAction{
+			Command: params.Command,
+			Args:    params.Args,
+			Env:     params.Env,
+			Dir:     params.Dir,
+			Timeout: params.Timeout,
+			Poll:    params.Poll,
+		}
+		if len(params.Colors) > 0 {
+			a.Colors = map[int]color.RGBA{}
+			for code, s := range params.Colors {
+				c, err := parseColor(s)
+				if err != nil {
+					return nil, err
+				}
+				a.Colors[code] = c
+			}
+		}
+		return a, nil
+	})
+}
+
+// CommandAction runs a command and shows how it went on the control
+// that triggered it: the last line of its output on the touch key, on
+// a band colored by its exit code, and the same color on the button's
+// LED.  With Poll set, profiles run it over and over while its page
+// is shown, to keep a status key up to date.
+//
+// On keys with a KeyButton, the status is shown with SetStatus, so
+// that it stays when the key is redrawn.  Other keys are drawn over.
+type CommandAction struct {
+	// Command is run with the system shell, unless Args is set, in
+	// which case it's run directly with Args as its arguments.
+	Command string
+	Args    []string
+	// Env holds environment variables to set, on top of this
+	// process's environment.
+	Env map[string]string
+	// Dir is the directory to run in, or the current directory.
+	Dir string
+	// Timeout stops the command if it runs for too long.  Zero
+	// means no timeout.
+	Timeout time.Duration
+	// Poll is how often to run the command again.  Zero means
+	// only when the control is used.
+	Poll time.Duration
+	// Colors maps exit codes to status colors.  By default, 0 is
+	// shown in the ActionFeedback's Success color, and anything
+	// else, including timeouts, in its Failure color.
+	Colors map[int]color.RGBA
+}
+
+// CommandResult is the outcome of running a CommandAction.
+type CommandResult struct {
+	// ExitCode is the command's exit code, or -1 if it couldn't
+	// be run or was stopped.
+	ExitCode int
+	// Output is the last non-empty line of the command's standard
+	// output.
+	Output   string
+	TimedOut bool
+	// Err is nil if the command exited with code 0.
+	Err error
+}
+
+// Run implements Action.  It fails if the command exits with a
+// non-zero code.
+func (a *CommandAction) Run(ctx context.Context, ac ActionContext) error {
+	r := a.Execute(ctx)
+	if ctx.Err() != nil && !r.TimedOut {
+		return r.Err
+	}
+	a.show(ac, r)
+	return r.Err
+}
+
+// Quiet implements QuietAction.  The command's status is shown
+// instead of a flash.
+func (a *CommandAction) Quiet() bool {
+	return true
+}
+
+// PollInterval implements PollingAction.
+func (a *CommandAction) PollInterval() time.Duration {
+	return a.Poll
+}
+
+// Execute runs the command and returns the result, without showing
+// it anywhere.
+func (a *CommandAction) Execute(ctx context.Context) CommandResult {
+	if a.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.Timeout)
+		defer cancel()
+	}
+
+	var cmd *exec.Cmd
+	if a.Args != nil {
+		cmd = exec.CommandContext(ctx, a.Command, a.Args...)
+		cmd.WaitDelay = time.Second
+	} else {
+		cmd = shellCommand(ctx, a.Command)
+	}
+	cmd.Dir = a.Dir
+	if len(a.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range a.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	slog.Debug("Running command", "command", a.Command, "args", a.Args)
+	err := cmd.Run()
+	r := CommandResult{ExitCode: cmd.ProcessState.ExitCode(), Output: lastLine(stdout.String())}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		r.TimedOut = true
+		r.Err = fmt.Errorf("timed out after %v", a.Timeout)
+	case errors.As(err, &exitErr):
+		if msg := lastLine(stderr.String()); msg != "" {
+			r.Err = fmt.Errorf("%v: %s", err, msg)
+		} else {
+			r.Err = err
+		}
+	case err != nil:
+		r.ExitCode = -1
+		r.Err = err
+	}
+	return r
+}
+
+// color returns the status color for a result.
+func (a *CommandAction) color(d *Device, r CommandResult) color.RGBA {
+	if c, ok := a.Colors[r.ExitCode]; ok && !r.TimedOut {
+		return c
+	}
+	feedback := d.actionFeedback()
+	if r.Err == nil {
+		return feedback.Success
+	}
+	return feedback.Failure
+}
+
+// show draws a result on the key and LED that triggered the action.
+func (a *CommandAction) show(ac ActionContext, r CommandResult) {
+	d := ac.Device
+	c := a.color(d, r)
+	text := r.Output
+	switch {
+	case r.TimedOut:
+		text = "timeout"
+	case text == "" && r.Err != nil:
+		text = fmt.Sprintf("exit %d", r.ExitCode)
+	}
+
+	if ac.Key != TouchNone {
+		var kb *KeyButton
+		if ac.Page != nil {
+			kb, _ = ac.Page.Widget(ac.Key).(*KeyButton)
+		}
+		if kb != nil {
+			kb.SetStatus(text, c)
+		} else if rect := d.TouchKeyRect(ac.Key); !rect.Empty() {
+			im, err := d.TextInBox(rect.Dx(), rect.Dy(), text, color.White, c)
+			if err != nil {
+				slog.Warn("Unable to draw command output", "err", err)
+			} else {
+				d.DrawTouchKey(ac.Key, im)
+			}
+		}
+	}
+	if b := ac.Button; b >= ButtonCircle && b <= Button7 {
+		d.setLED(b, c)
+	}
+}
+
+// lastLine returns the last non-empty line of s.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
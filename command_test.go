@@ -0,0 +1,79 @@
+package loupedeck
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestCommandExecute(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	tests := []struct {
+		name     string
+		a        *CommandAction
+		code     int
+		output   string
+		timedOut bool
+		failed   bool
+	}{
+		{"last line", &CommandAction{Command: "echo one; echo two; echo"}, 0, "two", false, false},
+		{"exit code", &CommandAction{Command: "echo down; exit 2"}, 2, "down", false, true},
+		{"args", &CommandAction{Command: "echo", Args: []string{"a b", "c"}}, 0, "a b c", false, false},
+		{"env", &CommandAction{Command: "echo $GREETING", Env: map[string]string{"GREETING": "hi"}}, 0, "hi", false, false},
+		{"dir", &CommandAction{Command: "pwd", Dir: "/"}, 0, "/", false, false},
+		{"timeout", &CommandAction{Command: "sleep 5", Timeout: 50 * time.Millisecond}, -1, "", true, true},
+		{"missing program", &CommandAction{Command: "/does/not/exist", Args: []string{}}, -1, "", false, true},
+	}
+	for _, tt := range tests {
+		r := tt.a.Execute(context.Background())
+		if r.ExitCode != tt.code || r.Output != tt.output || r.TimedOut != tt.timedOut || (r.Err != nil) != tt.failed {
+			t.Errorf("%s: Execute() = %+v, want exit code %d, output %q, timed out %v, failed %v",
+				tt.name, r, tt.code, tt.output, tt.timedOut, tt.failed)
+		}
+	}
+}
+
+func TestCommandColor(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	warn := color.RGBA{255, 200, 0, 255}
+	a := &CommandAction{Colors: map[int]color.RGBA{1: warn}}
+	f := DefaultActionFeedback
+	exitErr := errors.New("exit status 1")
+
+	tests := []struct {
+		name string
+		r    CommandResult
+		want color.RGBA
+	}{
+		{"success", CommandResult{ExitCode: 0}, f.Success},
+		{"mapped code", CommandResult{ExitCode: 1, Err: exitErr}, warn},
+		{"unmapped code", CommandResult{ExitCode: 2, Err: exitErr}, f.Failure},
+		{"timeout", CommandResult{ExitCode: -1, TimedOut: true, Err: context.DeadlineExceeded}, f.Failure},
+	}
+	for _, tt := range tests {
+		if got := a.color(d, tt.r); got != tt.want {
+			t.Errorf("%s: color = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLastLine(t *testing.T) {
+	tests := []struct {
+		s, want string
+	}{
+		{"", ""},
+		{"one", "one"},
+		{"one\ntwo\n", "two"},
+		{"one\n  two  \n\n", "two"},
+	}
+	for _, tt := range tests {
+		if got := lastLine(tt.s); got != tt.want {
+			t.Errorf("lastLine(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,798 @@
+package loupedeck
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Icons may be JPEGs.
+	_ "image/png"  // Icons may be PNGs.
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileConfig is a profile read from a YAML or JSON file by
+// ParseProfile or LoadProfileFile.  It describes a set of pages, with
+// the labels, icons, and colors of their keys, the LED colors of
+// their buttons, what their knobs control, and the actions run when
+// controls are used.  A short example:
+//
+//	values:
+//	  volume: {min: 0, max: 100, default: 50}
+//	pages:
+//	  - name: home
+//	    keys:
+//	      Touch1: {label: Volume, gauge: volume}
+//	      Touch2: {label: Lights, icon: bulb.png, action: {type: push, page: lights}}
+//	      Touch3: {label: Build, action: {type: shell, command: make, timeout: 5m}}
+//	      Touch4: {label: Disk, action: {type: command, command: "df -h / | tail -1", poll: 30s}}
+//	    buttons:
+//	      Button1: {led: "#00ff00", action: {type: show, page: home}}
+//	    knobs:
+//	      Knob1: {value: volume}
+//	  - name: lights
+//	    keys:
+//	      Touch1:
+//	        label: Movie
+//	        action:
+//	          type: sequence
+//	          steps:
+//	            - {type: http, url: "http://localhost:8080/lights/dim"}
+//	            - {type: delay, duration: 2s}
+//	            - {type: set, value: volume, to: 30}
+//	    buttons:
+//	      Button1: {led: "#ff0000", action: {type: back}}
+//
+// Controls are named after their constants: Touch1, TouchLeft,
+// Button1, KnobButton1, Knob1, CTKnob, and so on.  Action types are
+// the ones registered with RegisterAction: shell, command, http,
+// show, push, back, next, previous, set, sequence, delay, and cancel
+// are built in.
+type ProfileConfig struct {
+	Values map[string]*valueConfig `yaml:"values"`
+	Pages  []*pageConfig           `yaml:"pages"`
+
+	// file is the name used in error messages, and dir is where
+	// icons are loaded from.
+	file string
+	dir  string
+}
+
+// valueConfig describes a named value that knobs and gauges share.
+type valueConfig struct {
+	Min     float64  `yaml:"min"`
+	Max     float64  `yaml:"max"`
+	Step    *float64 `yaml:"step"`
+	Default float64  `yaml:"default"`
+	Wrap    bool     `yaml:"wrap"`
+	Format  string   `yaml:"format"`
+	line    int
+}
+
+type pageConfig struct {
+	Name    string                   `yaml:"name"`
+	Keys    map[string]*keyConfig    `yaml:"keys"`
+	Buttons map[string]*buttonConfig `yaml:"buttons"`
+	Knobs   map[string]*knobConfig   `yaml:"knobs"`
+	line    int
+}
+
+type keyConfig struct {
+	Label      string        `yaml:"label"`
+	Icon       string        `yaml:"icon"`
+	Color      string        `yaml:"color"`
+	Background string        `yaml:"background"`
+	Gauge      string        `yaml:"gauge"`
+	Action     *actionConfig `yaml:"action"`
+	line       int
+	icon       image.Image
+}
+
+type buttonConfig struct {
+	LED    string        `yaml:"led"`
+	Action *actionConfig `yaml:"action"`
+	line   int
+}
+
+type knobConfig struct {
+	Value  string        `yaml:"value"`
+	Action *actionConfig `yaml:"action"`
+	line   int
+}
+
+// actionConfig describes what happens when a control is used.  The
+// action is built when the profile is parsed, by the ActionFactory
+// registered for its type.  Errors are kept in err, so that
+// Validate can report them along with all of the others.
+type actionConfig struct {
+	Type   string
+	action Action
+	err    *ProfileError
+	line   int
+}
+
+// ProfileError is an error in a profile file.
+type ProfileError struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *ProfileError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// ProfileErrors is a list of errors found in a profile file, in line
+// order.
+type ProfileErrors []*ProfileError
+
+func (e ProfileErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// LoadProfileFile reads a profile from a YAML or JSON file.  Icons
+// are loaded relative to the file's directory.
+func LoadProfileFile(path string) (*ProfileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := ParseProfile(path, data)
+	if err != nil {
+		return nil, err
+	}
+	c.dir = filepath.Dir(path)
+	return c, nil
+}
+
+// ParseProfile parses a profile in YAML or JSON.  The name is only
+// used in error messages.  Errors are returned as ProfileErrors.
+func ParseProfile(name string, data []byte) (*ProfileConfig, error) {
+	c := &ProfileConfig{file: name, dir: "."}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, c.wrapError(err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, ProfileErrors{{File: name, Msg: "profile is empty"}}
+	}
+	if err := doc.Content[0].Decode(c); err != nil {
+		return nil, c.wrapError(err)
+	}
+	return c, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (c *ProfileConfig) UnmarshalYAML(n *yaml.Node) error {
+	type plain ProfileConfig
+	if err := decodeStrict(n, (*plain)(c)); err != nil {
+		return err
+	}
+	if err := checkEmpty(n, "values", "value"); err != nil {
+		return err
+	}
+	return checkEmpty(n, "pages", "page")
+}
+
+func (c *valueConfig) UnmarshalYAML(n *yaml.Node) error {
+	type plain valueConfig
+	c.line = n.Line
+	return decodeStrict(n, (*plain)(c))
+}
+
+func (c *pageConfig) UnmarshalYAML(n *yaml.Node) error {
+	type plain pageConfig
+	c.line = n.Line
+	if err := decodeStrict(n, (*plain)(c)); err != nil {
+		return err
+	}
+	for _, f := range [][2]string{{"keys", "key"}, {"buttons", "button"}, {"knobs", "knob"}} {
+		if err := checkEmpty(n, f[0], f[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *keyConfig) UnmarshalYAML(n *yaml.Node) error {
+	type plain keyConfig
+	c.line = n.Line
+	return decodeStrict(n, (*plain)(c))
+}
+
+func (c *buttonConfig) UnmarshalYAML(n *yaml.Node) error {
+	type plain buttonConfig
+	c.line = n.Line
+	return decodeStrict(n, (*plain)(c))
+}
+
+func (c *knobConfig) UnmarshalYAML(n *yaml.Node) error {
+	type plain knobConfig
+	c.line = n.Line
+	return decodeStrict(n, (*plain)(c))
+}
+
+func (c *actionConfig) UnmarshalYAML(n *yaml.Node) error {
+	c.line = n.Line
+	if n.Kind != yaml.MappingNode {
+		return &ProfileError{Line: n.Line, Msg: fmt.Sprintf("expected a mapping, found %s", nodeKind(n))}
+	}
+	for i := 0; i < len(n.Content); i += 2 {
+		if n.Content[i].Value == "type" {
+			c.Type = n.Content[i+1].Value
+		}
+	}
+
+	f := actionFactory(c.Type)
+	switch {
+	case c.Type == "":
+		c.err = &ProfileError{Line: n.Line, Msg: "action has no type"}
+	case f == nil:
+		c.err = &ProfileError{Line: n.Line, Msg: fmt.Sprintf("unknown action type %q (expected one of %s)", c.Type, strings.Join(ActionTypes(), ", "))}
+	default:
+		a, err := f(func(params any) error { return decodeStrict(n, params, "type") })
+		if err != nil {
+			c.err = actionError(n.Line, err)
+		}
+		c.action = a
+	}
+	return nil
+}
+
+// actionError turns an error from an ActionFactory into a
+// ProfileError.
+func actionError(line int, err error) *ProfileError {
+	var pe *ProfileError
+	var te *yaml.TypeError
+	switch {
+	case errors.As(err, &pe):
+		return pe
+	case errors.As(err, &te) && len(te.Errors) > 0:
+		return yamlError("", te.Errors[0])
+	}
+	return &ProfileError{Line: line, Msg: err.Error()}
+}
+
+// decodeStrict decodes a mapping into out, which must be a pointer to
+// a struct, and rejects any keys that out doesn't have a field for,
+// other than those in extra.
+func decodeStrict(n *yaml.Node, out any, extra ...string) error {
+	if n.Kind != yaml.MappingNode {
+		return &ProfileError{Line: n.Line, Msg: fmt.Sprintf("expected a mapping, found %s", nodeKind(n))}
+	}
+	fields := map[string]bool{}
+	for _, name := range extra {
+		fields[name] = true
+	}
+	t := reflect.TypeOf(out).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		switch {
+		case !f.IsExported() || name == "-":
+			continue
+		case name == "":
+			// The yaml package's default name.
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = true
+	}
+	for i := 0; i < len(n.Content); i += 2 {
+		k := n.Content[i]
+		if !fields[k.Value] {
+			known := make([]string, 0, len(fields))
+			for f := range fields {
+				known = append(known, f)
+			}
+			sort.Strings(known)
+			return &ProfileError{Line: k.Line, Msg: fmt.Sprintf("unknown field %q (expected one of %s)", k.Value, strings.Join(known, ", "))}
+		}
+	}
+	return n.Decode(out)
+}
+
+func nodeKind(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.SequenceNode:
+		return "a list"
+	case yaml.ScalarNode:
+		return fmt.Sprintf("%q", n.Value)
+	}
+	return "something else"
+}
+
+// checkEmpty returns an error for the first empty item in the list
+// or mapping in field of the mapping n.  The yaml package decodes
+// those as nil pointers.
+func checkEmpty(n *yaml.Node, field, what string) error {
+	isNull := func(n *yaml.Node) bool {
+		return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null"
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value != field {
+			continue
+		}
+		v := n.Content[i+1]
+		switch v.Kind {
+		case yaml.SequenceNode:
+			for _, item := range v.Content {
+				if isNull(item) {
+					return &ProfileError{Line: item.Line, Msg: fmt.Sprintf("empty %s", what)}
+				}
+			}
+		case yaml.MappingNode:
+			for j := 0; j+1 < len(v.Content); j += 2 {
+				if isNull(v.Content[j+1]) {
+					return &ProfileError{Line: v.Content[j].Line, Msg: fmt.Sprintf("%s %q is empty", what, v.Content[j].Value)}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// wrapError turns errors from the yaml package into ProfileErrors.
+func (c *ProfileConfig) wrapError(err error) error {
+	var pe *ProfileError
+	var te *yaml.TypeError
+	switch {
+	case errors.As(err, &pe):
+		pe.File = c.file
+		return ProfileErrors{pe}
+	case errors.As(err, &te):
+		errs := make(ProfileErrors, len(te.Errors))
+		for i, msg := range te.Errors {
+			errs[i] = yamlError(c.file, msg)
+		}
+		return errs
+	}
+	return ProfileErrors{yamlError(c.file, strings.TrimPrefix(err.Error(), "yaml: "))}
+}
+
+// yamlError turns a message like "line 3: something" into a
+// ProfileError.
+func yamlError(file, msg string) *ProfileError {
+	e := &ProfileError{File: file, Msg: msg}
+	if rest, ok := strings.CutPrefix(msg, "line "); ok {
+		if n, m, ok := strings.Cut(rest, ": "); ok {
+			if line, err := strconv.Atoi(n); err == nil {
+				e.Line, e.Msg = line, m
+			}
+		}
+	}
+	return e
+}
+
+// Validate checks the profile against the device's model, and
+// checks that its colors, icons, and references are valid.  Icons are
+// loaded as part of validation.
+func (c *ProfileConfig) Validate(d *Device) error {
+	var errs ProfileErrors
+	fail := func(line int, format string, args ...any) {
+		errs = append(errs, &ProfileError{File: c.file, Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	for name, v := range c.Values {
+		if v.Max < v.Min {
+			fail(v.line, "value %q has max %v below min %v", name, v.Max, v.Min)
+		}
+		if v.Default < v.Min || v.Default > v.Max {
+			fail(v.line, "value %q has default %v outside of %v to %v", name, v.Default, v.Min, v.Max)
+		}
+		if v.Step != nil && *v.Step <= 0 {
+			fail(v.line, "value %q has a step of %v, which must be positive", name, *v.Step)
+		}
+	}
+
+	if len(c.Pages) == 0 {
+		fail(0, "no pages defined")
+	}
+	pages := map[string]bool{}
+	for _, p := range c.Pages {
+		switch {
+		case p.Name == "":
+			fail(p.line, "page has no name")
+		case pages[p.Name]:
+			fail(p.line, "page %q is defined more than once", p.Name)
+		}
+		pages[p.Name] = true
+	}
+
+	checkColor := func(line int, s string) {
+		if s != "" {
+			if _, err := parseColor(s); err != nil {
+				fail(line, "%v", err)
+			}
+		}
+	}
+	checkValue := func(line int, name string) {
+		if name != "" && c.Values[name] == nil {
+			fail(line, "no value named %q", name)
+		}
+	}
+	// checkRefs checks the pages and values that built-in actions
+	// refer to.
+	var checkRefs func(line int, a Action)
+	checkRefs = func(line int, a Action) {
+		switch a := a.(type) {
+		case *PageAction:
+			if a.Page != "" && !pages[a.Page] {
+				fail(line, "no page named %q", a.Page)
+			}
+		case *SetAction:
+			checkValue(line, a.Value)
+		case *SequenceAction:
+			for _, step := range a.Steps {
+				checkRefs(line, step)
+			}
+		}
+	}
+	checkAction := func(a *actionConfig) {
+		switch {
+		case a == nil:
+		case a.err != nil:
+			line := a.err.Line
+			if line == 0 {
+				line = a.line
+			}
+			fail(line, "%s", a.err.Msg)
+		default:
+			checkRefs(a.line, a.action)
+		}
+	}
+
+	for _, p := range c.Pages {
+		for name, k := range p.Keys {
+			b, ok := touchButtonNames[name]
+			if !ok {
+				fail(k.line, "unknown key %q", name)
+			} else if d.TouchKeyRect(b).Empty() {
+				fail(k.line, "%s doesn't have key %s", d.Model, name)
+			}
+			checkColor(k.line, k.Color)
+			checkColor(k.line, k.Background)
+			checkValue(k.line, k.Gauge)
+			if k.Gauge != "" && k.Action != nil {
+				fail(k.line, "key %s can't have both a gauge and an action", name)
+			}
+			checkAction(k.Action)
+			if k.Icon != "" {
+				im, err := loadIcon(filepath.Join(c.dir, k.Icon))
+				if err != nil {
+					fail(k.line, "unable to load icon: %v", err)
+				}
+				k.icon = im
+			}
+		}
+		for name, bc := range p.Buttons {
+			b, ok := buttonNames[name]
+			if !ok {
+				fail(bc.line, "unknown button %q", name)
+			} else if !d.model.hasButton(b) {
+				fail(bc.line, "%s doesn't have button %s", d.Model, name)
+			}
+			checkColor(bc.line, bc.LED)
+			checkAction(bc.Action)
+		}
+		for name, kc := range p.Knobs {
+			k, ok := knobNames[name]
+			if !ok {
+				fail(kc.line, "unknown knob %q", name)
+			} else if !d.model.hasKnob(k) {
+				fail(kc.line, "%s doesn't have knob %s", d.Model, name)
+			}
+			checkValue(kc.line, kc.Value)
+			checkAction(kc.Action)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return errs
+}
+
+// Apply validates the profile and builds a Profile from it on the
+// device, showing its first page.
+func (c *ProfileConfig) Apply(d *Device) (*Profile, error) {
+	a, err := c.apply(d)
+	if err != nil {
+		return nil, err
+	}
+	return a.profile, nil
+}
+
+// appliedProfile records what was built for each control of a
+// ProfileConfig, so that a reload can replace only the controls that
+// changed.
+type appliedProfile struct {
+	config  *ProfileConfig
+	profile *Profile
+	pages   map[string]*appliedPage
+}
+
+type appliedPage struct {
+	page    *Page
+	keys    map[string]*Binding
+	buttons map[string]*Binding
+	knobs   map[string]*Binding
+}
+
+func (c *ProfileConfig) apply(d *Device) (*appliedProfile, error) {
+	if err := c.Validate(d); err != nil {
+		return nil, err
+	}
+
+	a := &appliedProfile{config: c, profile: NewProfile(d), pages: map[string]*appliedPage{}}
+	for name, vc := range c.Values {
+		a.profile.SetValue(name, vc.watched())
+	}
+	for _, pc := range c.Pages {
+		a.applyPage(pc)
+	}
+	if err := a.profile.Show(c.Pages[0].Name); err != nil {
+		a.close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// close removes everything that apply built: it unbinds every
+// control, closes the widgets, and closes the profile.
+func (a *appliedProfile) close() {
+	for _, ap := range a.pages {
+		a.clearPage(ap)
+	}
+	a.pages = map[string]*appliedPage{}
+	a.profile.Close()
+}
+
+// clearPage unbinds every control on a page and removes its widgets.
+func (a *appliedProfile) clearPage(ap *appliedPage) {
+	for name := range ap.keys {
+		a.applyKey(ap, name, nil)
+	}
+	for _, b := range ap.buttons {
+		b.Unbind()
+	}
+	for _, b := range ap.knobs {
+		b.Unbind()
+	}
+}
+
+func (a *appliedProfile) applyPage(pc *pageConfig) {
+	ap := &appliedPage{
+		page:    a.profile.AddPage(pc.Name),
+		keys:    map[string]*Binding{},
+		buttons: map[string]*Binding{},
+		knobs:   map[string]*Binding{},
+	}
+	a.pages[pc.Name] = ap
+
+	for name, kc := range pc.Keys {
+		ap.keys[name] = a.applyKey(ap, name, kc)
+	}
+	for name, bc := range pc.Buttons {
+		ap.buttons[name] = a.applyButton(ap, name, bc)
+	}
+	for name, kc := range pc.Knobs {
+		ap.knobs[name] = a.applyKnob(ap, name, kc)
+	}
+}
+
+// applyKey places the widget for a key on a page, replacing any
+// widget that was there, and returns the Binding for polling its
+// action, if any.  A nil keyConfig just removes the old widget.
+func (a *appliedProfile) applyKey(ap *appliedPage, name string, kc *keyConfig) *Binding {
+	b := touchButtonNames[name]
+	ap.keys[name].Unbind()
+	if old, ok := ap.page.Widget(b).(interface{ Close() }); ok {
+		old.Close()
+	}
+	if kc == nil {
+		ap.page.SetWidget(b, nil)
+		return nil
+	}
+
+	fg, bg := color.Color(color.White), color.Color(color.Black)
+	if kc.Color != "" {
+		fg, _ = parseColor(kc.Color)
+	}
+	if kc.Background != "" {
+		bg, _ = parseColor(kc.Background)
+	}
+
+	p := a.profile
+	if kc.Gauge != "" {
+		label := kc.Label
+		if label == "" {
+			label = kc.Gauge
+		}
+		g := NewAnalogGauge(label, p.Value(kc.Gauge), fg, bg)
+		if f := a.config.Values[kc.Gauge].format(); f != "" {
+			g.SetFormat(f)
+		}
+		ap.page.SetWidget(b, g)
+		return nil
+	}
+	ac := ActionContext{Page: ap.page, Key: b}
+	ap.page.SetWidget(b, NewKeyButton(kc.Label, kc.icon, fg, bg, p.action(kc.Action, ac)))
+	return a.poll(ap, kc.Action, ac)
+}
+
+// applyButton binds a button's LED and action on a page.
+func (a *appliedProfile) applyButton(ap *appliedPage, name string, bc *buttonConfig) *Binding {
+	b := buttonNames[name]
+	var bindings []*Binding
+	if bc.LED != "" {
+		led, _ := parseColor(bc.LED)
+		bindings = append(bindings, ap.page.Bind(func(d *Device) *Binding {
+			d.setLED(b, led)
+			return newBinding(func() { d.setLED(b, color.RGBA{}) })
+		}))
+	}
+	ac := ActionContext{Page: ap.page, Button: b}
+	if f := a.profile.action(bc.Action, ac); f != nil {
+		bindings = append(bindings, ap.page.BindButton(b, func(Button, ButtonState) { f() }))
+	}
+	bindings = append(bindings, a.poll(ap, bc.Action, ac))
+	return joinBindings(bindings...)
+}
+
+// applyKnob binds a knob's value and action on a page.
+func (a *appliedProfile) applyKnob(ap *appliedPage, name string, kc *knobConfig) *Binding {
+	k := knobNames[name]
+	var bindings []*Binding
+	if kc.Value != "" {
+		w := a.profile.Value(kc.Value)
+		bindings = append(bindings, ap.page.Bind(func(d *Device) *Binding {
+			return d.BindKnob(k, func(_ Knob, delta int) { w.Add(delta) })
+		}))
+		if kc.Action == nil && k != CTKnob {
+			bindings = append(bindings, ap.page.BindButton(Button(k), func(Button, ButtonState) { w.Reset() }))
+		}
+	}
+	if f := a.profile.action(kc.Action, ActionContext{Page: ap.page, Button: Button(k)}); f != nil {
+		bindings = append(bindings, ap.page.BindButton(Button(k), func(Button, ButtonState) { f() }))
+	}
+	return joinBindings(bindings...)
+}
+
+// poll polls an action while its page is shown, if it's a
+// PollingAction with an interval, and returns the Binding for it, or
+// nil.
+func (a *appliedProfile) poll(ap *appliedPage, c *actionConfig, ac ActionContext) *Binding {
+	if c == nil {
+		return nil
+	}
+	pa, ok := c.action.(PollingAction)
+	if !ok || pa.PollInterval() <= 0 {
+		return nil
+	}
+	ac.Profile = a.profile
+	return ap.page.Bind(func(d *Device) *Binding {
+		return d.PollAction(pa, ac, pa.PollInterval())
+	})
+}
+
+// setLED sets a button's LED color, logging any error.
+func (d *Device) setLED(b Button, c color.RGBA) {
+	if err := d.SetButtonColor(b, c); err != nil {
+		slog.Warn("Unable to set button color", "button", b, "err", err)
+	}
+}
+
+// action returns a function that runs a configured action, with ac
+// saying what triggered it, or nil.
+func (p *Profile) action(a *actionConfig, ac ActionContext) func() {
+	if a == nil {
+		return nil
+	}
+	ac.Profile = p
+	return func() {
+		slog.Info("Running action", "type", a.Type)
+		p.device.RunAction(a.action, ac)
+	}
+}
+
+// watched creates the Watched value for a valueConfig.
+func (v *valueConfig) watched() *Watched[float64] {
+	w := NewWatched(v.Default, v.Min, v.Max, v.step())
+	if v.Wrap {
+		w.SetMode(Wrap)
+	}
+	return w
+}
+
+// step returns how far the value moves per knob tick.
+func (v *valueConfig) step() float64 {
+	if v.Step != nil {
+		return *v.Step
+	}
+	return 1
+}
+
+// format returns the format for showing the value.  Values with
+// whole-number steps and bounds are shown without decimals.
+func (v *valueConfig) format() string {
+	if v.Format != "" {
+		return v.Format
+	}
+	whole := func(x float64) bool { return x == float64(int64(x)) }
+	if whole(v.Min) && whole(v.Max) && whole(v.Default) && (v.Step == nil || whole(*v.Step)) {
+		return "%.0f"
+	}
+	return ""
+}
+
+// parseColor parses a color written as #rgb or #rrggbb.
+func parseColor(s string) (color.RGBA, error) {
+	hex, ok := strings.CutPrefix(s, "#")
+	if ok && len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if !ok || len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q (expected #rgb or #rrggbb)", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q (expected #rgb or #rrggbb)", s)
+	}
+	return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, nil
+}
+
+// loadIcon reads a PNG or JPEG image.
+func loadIcon(path string) (image.Image, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	im, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return im, nil
+}
+
+// Names used for controls in profile files.
+var (
+	touchButtonNames = map[string]TouchButton{
+		"Touch1": Touch1, "Touch2": Touch2, "Touch3": Touch3, "Touch4": Touch4,
+		"Touch5": Touch5, "Touch6": Touch6, "Touch7": Touch7, "Touch8": Touch8,
+		"Touch9": Touch9, "Touch10": Touch10, "Touch11": Touch11, "Touch12": Touch12,
+		"Touch13": Touch13, "Touch14": Touch14, "Touch15": Touch15,
+		"TouchLeft": TouchLeft, "TouchRight": TouchRight, "TouchWheel": TouchWheel,
+	}
+	buttonNames = map[string]Button{
+		"KnobButton1": KnobButton1, "KnobButton2": KnobButton2, "KnobButton3": KnobButton3,
+		"KnobButton4": KnobButton4, "KnobButton5": KnobButton5, "KnobButton6": KnobButton6,
+		"ButtonCircle": ButtonCircle, "Button0": Button0, "Button1": Button1,
+		"Button2": Button2, "Button3": Button3, "Button4": Button4,
+		"Button5": Button5, "Button6": Button6, "Button7": Button7,
+		"CTCircle": CTCircle, "Undo": Undo, "Keyboard": Keyboard, "Enter": Enter,
+		"Save": Save, "LeftFn": LeftFn, "RightFn": RightFn,
+		"Up": Up, "Down": Down, "Left": Left, "Right": Right,
+		"A": A, "B": B, "C": C, "D": D, "E": E,
+	}
+	knobNames = map[string]Knob{
+		"CTKnob": CTKnob, "Knob1": Knob1, "Knob2": Knob2, "Knob3": Knob3,
+		"Knob4": Knob4, "Knob5": Knob5, "Knob6": Knob6,
+	}
+)
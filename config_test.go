@@ -0,0 +1,140 @@
+package loupedeck
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseProfileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		line int
+		msg  string
+	}{
+		{"empty", "", 0, "profile is empty"},
+		{"syntax", "pages:\n  - name: home\n\tkeys: {}\n", 2, "tab character"},
+		{"unknown field", "pages:\n  - name: home\n    keyz: {}\n", 3, "keyz"},
+		{"not a mapping", "pages:\n  - home\n", 2, "expected a mapping"},
+		{"unknown action", "pages:\n  - name: home\n    keys:\n      Touch1:\n        action: {type: teleport}\n", 5, "teleport"},
+		{"bad action field", "pages:\n  - name: home\n    keys:\n      Touch1:\n        action: {type: shell, command: ls, shell: bash}\n", 5, "shell"},
+		{"empty page", "pages:\n  - name: home\n  -\n", 3, "empty page"},
+		{"empty key", "pages:\n  - name: home\n    keys:\n      Touch1:\n", 4, `key "Touch1" is empty`},
+		{"null key", "pages:\n  - name: home\n    keys: {Touch1: ~}\n", 3, `key "Touch1" is empty`},
+		{"empty button", "pages:\n  - name: home\n    buttons:\n      Button1:\n", 4, `button "Button1" is empty`},
+		{"empty knob", "pages:\n  - name: home\n    knobs:\n      Knob1:\n", 4, `knob "Knob1" is empty`},
+		{"empty value", "values:\n  volume:\npages:\n  - name: home\n", 2, `value "volume" is empty`},
+		{"missing action param", "pages:\n  - name: home\n    buttons:\n      Button1:\n        action: {type: show}\n", 5, "needs a page"},
+	}
+	for _, tt := range tests {
+		c, err := ParseProfile("test.yaml", []byte(tt.yaml))
+		if err == nil {
+			// Action errors are reported by Validate, along
+			// with the others.
+			err = c.Validate(CreateDevice(&SerialWebSockConn{Product: "0004"}))
+		}
+		var errs ProfileErrors
+		if !errors.As(err, &errs) || len(errs) == 0 {
+			t.Errorf("%s: got error %v, want ProfileErrors", tt.name, err)
+			continue
+		}
+		if e := errs[0]; e.File != "test.yaml" || e.Line != tt.line || !strings.Contains(e.Msg, tt.msg) {
+			t.Errorf("%s: got %q, want line %d containing %q", tt.name, e, tt.line, tt.msg)
+		}
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		product string
+		yaml    string
+		lines   []int
+	}{
+		{"valid", "0004", "pages:\n  - name: home\n    keys:\n      Touch1: {label: Hi}\n", nil},
+		{"no pages", "0004", "values: {}\n", []int{0}},
+		{"bad range", "0004", "values:\n  v: {min: 10, max: 0}\npages:\n  - name: home\n", []int{2, 2}},
+		{"unknown page", "0004", "pages:\n  - name: home\n    buttons:\n      Button1: {action: {type: show, page: away}}\n", []int{4}},
+		{"unknown value", "0004", "pages:\n  - name: home\n    knobs:\n      Knob1: {value: volume}\n", []int{4}},
+		{"missing key", "0004", "pages:\n  - name: home\n    keys:\n      Touch13: {label: Hi}\n", []int{4}},
+		{"Live S key", "0006", "pages:\n  - name: home\n    keys:\n      Touch13: {label: Hi}\n", nil},
+		{"unknown control", "0004", "pages:\n  - name: home\n    keys:\n      Touch99: {}\n    knobs:\n      Knob9: {}\n", []int{4, 6}},
+		{"gauge with an action", "0004", "values:\n  v: {max: 10}\npages:\n  - name: home\n    keys:\n      Touch1: {gauge: v, action: {type: back}}\n", []int{6}},
+		{"bad color", "0004", "pages:\n  - name: home\n    keys:\n      Touch1: {color: blurple}\n", []int{4}},
+	}
+	for _, tt := range tests {
+		c, err := ParseProfile("test.yaml", []byte(tt.yaml))
+		if err != nil {
+			t.Errorf("%s: ParseProfile() failed: %v", tt.name, err)
+			continue
+		}
+		err = c.Validate(CreateDevice(&SerialWebSockConn{Product: tt.product}))
+		var errs ProfileErrors
+		if err != nil && !errors.As(err, &errs) {
+			t.Errorf("%s: got error %v, want ProfileErrors", tt.name, err)
+			continue
+		}
+		var lines []int
+		for _, e := range errs {
+			lines = append(lines, e.Line)
+		}
+		if len(lines) != len(tt.lines) {
+			t.Errorf("%s: got errors %v, want them on lines %v", tt.name, err, tt.lines)
+			continue
+		}
+		for i := range lines {
+			if lines[i] != tt.lines[i] {
+				t.Errorf("%s: got errors %v, want them on lines %v", tt.name, err, tt.lines)
+				break
+			}
+		}
+	}
+}
+
+func TestAppliedProfileClose(t *testing.T) {
+	d := newTestDevice(t, "0004")
+	c, err := ParseProfile("test.yaml", []byte(`
+values:
+  volume: {min: 0, max: 100, default: 50}
+pages:
+  - name: home
+    keys:
+      Touch1: {label: Volume, gauge: volume}
+      Touch2: {label: Away, action: {type: show, page: away}}
+    buttons:
+      Button1: {led: "#00ff00", action: {type: show, page: away}}
+    knobs:
+      Knob1: {value: volume}
+  - name: away
+    buttons:
+      Button1: {action: {type: back}}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Count the bindings on each control, including the
+	// wildcard ones that the device always has.
+	count := func() [2]int {
+		return [2]int{len(d.bindings.button.get(Button1)), len(d.bindings.knob.get(Knob1))}
+	}
+	before := count()
+	a, err := c.apply(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := count(); n == before {
+		t.Errorf("apply didn't bind Button1 and Knob1: %v bindings before, %v after", before, n)
+	}
+
+	a.close()
+	if n := count(); n != before {
+		t.Errorf("close left bindings behind: %v bindings before apply, %v after close", before, n)
+	}
+	if w := a.profile.container.Widget(Touch1); w != nil {
+		t.Errorf("Touch1 still has a widget after close: %v", w)
+	}
+	if p := a.profile.Current(); p != nil {
+		t.Errorf("page %q is still current after close", p.Name)
+	}
+}
@@ -0,0 +1,162 @@
+package loupedeck
+
+import (
+	"math"
+	"sort"
+)
+
+// Curve maps a knob position onto a parameter value.  Both are
+// normalized to the range [0,1]; Map turns a position into a value,
+// and Inverse turns a value back into the position that produces
+// it, so that a knob's position can follow a value that is changed
+// from elsewhere.
+//
+// Curves should be monotonically increasing, with Map(0) == 0 and
+// Map(1) == 1.
+type Curve interface {
+	Map(position float64) float64
+	Inverse(value float64) float64
+}
+
+// LinearCurve maps positions onto values unchanged.
+type LinearCurve struct{}
+
+// Map implements Curve.
+func (LinearCurve) Map(p float64) float64 { return clamp01(p) }
+
+// Inverse implements Curve.
+func (LinearCurve) Inverse(v float64) float64 { return clamp01(v) }
+
+// LogCurve rises quickly at first and then flattens out, giving
+// fine control at the top of the range.  Larger values of K make
+// the curve more pronounced; K must be greater than 0.
+type LogCurve struct {
+	K float64
+}
+
+// Map implements Curve.
+func (c LogCurve) Map(p float64) float64 {
+	return math.Log1p(c.K*clamp01(p)) / math.Log1p(c.K)
+}
+
+// Inverse implements Curve.
+func (c LogCurve) Inverse(v float64) float64 {
+	return math.Expm1(clamp01(v)*math.Log1p(c.K)) / c.K
+}
+
+// ExpCurve starts slowly and rises quickly, giving fine control at
+// the bottom of the range.  This is the shape that audio faders
+// (confusingly called a "logarithmic taper") and zoom controls
+// want.  Larger values of K make the curve more pronounced; K must be
+// greater than 0.
+type ExpCurve struct {
+	K float64
+}
+
+// Map implements Curve.
+func (c ExpCurve) Map(p float64) float64 {
+	return math.Expm1(c.K*clamp01(p)) / math.Expm1(c.K)
+}
+
+// Inverse implements Curve.
+func (c ExpCurve) Inverse(v float64) float64 {
+	return math.Log1p(clamp01(v)*math.Expm1(c.K)) / c.K
+}
+
+// GammaCurve raises the position to the power Gamma, as used for
+// lighting levels.  Gamma values above 1 give fine control at the
+// bottom of the range.
+type GammaCurve struct {
+	Gamma float64
+}
+
+// Map implements Curve.
+func (c GammaCurve) Map(p float64) float64 {
+	return math.Pow(clamp01(p), c.Gamma)
+}
+
+// Inverse implements Curve.
+func (c GammaCurve) Inverse(v float64) float64 {
+	return math.Pow(clamp01(v), 1/c.Gamma)
+}
+
+// SCurve gives fine control at both ends of the range and moves
+// quickly through the middle.  Larger values of K make the curve
+// more pronounced; K must be greater than 0.
+type SCurve struct {
+	K float64
+}
+
+// Map implements Curve.
+func (c SCurve) Map(p float64) float64 {
+	lo, hi := c.sigmoid(0), c.sigmoid(1)
+	return (c.sigmoid(clamp01(p)) - lo) / (hi - lo)
+}
+
+// Inverse implements Curve.
+func (c SCurve) Inverse(v float64) float64 {
+	lo, hi := c.sigmoid(0), c.sigmoid(1)
+	s := lo + clamp01(v)*(hi-lo)
+	return clamp01(0.5 + math.Log(s/(1-s))/c.K)
+}
+
+func (c SCurve) sigmoid(p float64) float64 {
+	return 1 / (1 + math.Exp(-c.K*(p-0.5)))
+}
+
+// LookupCurve is a custom curve given as a table of values at
+// evenly spaced positions, with linear interpolation between them.
+// The first entry is the value at position 0 and the last is the
+// value at position 1.  The values must be increasing.
+type LookupCurve []float64
+
+// Map implements Curve.
+func (c LookupCurve) Map(p float64) float64 {
+	if len(c) < 2 {
+		return clamp01(p)
+	}
+	x := clamp01(p) * float64(len(c)-1)
+	i := min(int(x), len(c)-2)
+	return c[i] + (x-float64(i))*(c[i+1]-c[i])
+}
+
+// Inverse implements Curve.
+func (c LookupCurve) Inverse(v float64) float64 {
+	if len(c) < 2 {
+		return clamp01(v)
+	}
+	v = math.Max(c[0], math.Min(c[len(c)-1], v))
+	i := sort.SearchFloat64s(c, v)
+	if i == 0 {
+		return 0
+	}
+	lo, hi := c[i-1], c[i]
+	frac := 0.0
+	if hi > lo {
+		frac = (v - lo) / (hi - lo)
+	}
+	return (float64(i-1) + frac) / float64(len(c)-1)
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// CurveKnobFunc returns a KnobFunc that maps knob turns through a
+// Curve onto a value between min and max, starting at initial, and
+// calls f with each new value.  A full sweep from min to max takes
+// steps ticks; steps less than 1 are treated as 1.  For values that
+// may also be changed elsewhere, use Watched.SetCurve instead.
+func CurveKnobFunc(c Curve, min, max, initial float64, steps int, f func(Knob, float64)) KnobFunc {
+	if steps < 1 {
+		steps = 1
+	}
+	position := 0.0
+	if max != min {
+		position = c.Inverse((initial - min) / (max - min))
+	}
+	return func(k Knob, delta int) {
+		position = clamp01(position + float64(delta)/float64(steps))
+		f(k, min+c.Map(position)*(max-min))
+	}
+}
@@ -0,0 +1,66 @@
+package loupedeck
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCurveInverse(t *testing.T) {
+	curves := []struct {
+		name string
+		c    Curve
+	}{
+		{"linear", LinearCurve{}},
+		{"log", LogCurve{K: 9}},
+		{"exp", ExpCurve{K: 4}},
+		{"gamma", GammaCurve{Gamma: 2.2}},
+		{"s", SCurve{K: 8}},
+		{"lookup", LookupCurve{0, 0.1, 0.3, 0.7, 1}},
+	}
+	for _, tt := range curves {
+		if v := tt.c.Map(0); math.Abs(v) > 1e-9 {
+			t.Errorf("%s: Map(0) = %v, want 0", tt.name, v)
+		}
+		if v := tt.c.Map(1); math.Abs(v-1) > 1e-9 {
+			t.Errorf("%s: Map(1) = %v, want 1", tt.name, v)
+		}
+		last := -1.0
+		for i := 0; i <= 20; i++ {
+			p := float64(i) / 20
+			v := tt.c.Map(p)
+			if v < last {
+				t.Errorf("%s: Map(%v) = %v, below Map of a smaller position (%v)", tt.name, p, v, last)
+			}
+			last = v
+			if got := tt.c.Inverse(v); math.Abs(got-p) > 1e-9 {
+				t.Errorf("%s: Inverse(Map(%v)) = %v", tt.name, p, got)
+			}
+		}
+	}
+}
+
+func TestCurveKnobFunc(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps int
+		turns []int
+		want  float64
+	}{
+		{"one step", 10, []int{1}, 10},
+		{"several steps", 10, []int{3, 2}, 50},
+		{"clamped at the top", 10, []int{20}, 100},
+		{"clamped at the bottom", 10, []int{-20}, 0},
+		{"zero steps", 0, []int{1}, 100},
+		{"negative steps", -5, []int{-1}, 0},
+	}
+	for _, tt := range tests {
+		var got float64
+		f := CurveKnobFunc(LinearCurve{}, 0, 100, 0, tt.steps, func(_ Knob, v float64) { got = v })
+		for _, delta := range tt.turns {
+			f(Knob1, delta)
+		}
+		if math.IsNaN(got) || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
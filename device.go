@@ -30,14 +30,37 @@ type Device struct {
 	fontdrawer           *font.Drawer
 	serial               *SerialWebSockConn
 	conn                 *websocket.Conn
-	buttonBindings       map[Button]ButtonFunc
-	buttonUpBindings     map[Button]ButtonFunc
-	knobBindings         map[Knob]KnobFunc
-	touchBindings        map[TouchButton]TouchFunc
-	touchUpBindings      map[TouchButton]TouchFunc
+	bindings             bindingTable
 	transactionID        uint8
 	transactionMutex     sync.Mutex
+	writeMutex           sync.Mutex
 	transactionCallbacks map[byte]transactionCallback
+	model                model
+	orientation          Orientation
+	touchKeys            []image.Rectangle
+	touchLeft            image.Rectangle
+	touchRight           image.Rectangle
+	knobToLogical        map[Knob]Knob
+	knobToPhysical       map[Knob]Knob
+	buttonToLogical      map[Button]Button
+	buttonToPhysical     map[Button]Button
+	regionSeq            int
+	touchMutex           sync.RWMutex
+	touches              map[touchKey]*TouchSession
+	pressedMutex         sync.RWMutex
+	pressed              map[Button]bool
+	events               eventStream
+	dispatchMutex        sync.RWMutex
+	pool                 *workerPool
+	panicHandler         PanicFunc
+	regionMutex          sync.RWMutex
+	knobMutex            sync.Mutex
+	knobs                map[Knob]*knobState
+	chords               chordState
+	presses              pressTable
+	actions              actionTable
+	ledMutex             sync.Mutex
+	leds                 map[Button]color.RGBA
 }
 
 func CreateDevice(s *SerialWebSockConn) *Device {
@@ -46,13 +69,26 @@ func CreateDevice(s *SerialWebSockConn) *Device {
 	d := &Device{
 		Vendor:               s.Vendor,
 		Product:              s.Product,
-		buttonBindings:       make(map[Button]ButtonFunc),
-		buttonUpBindings:     make(map[Button]ButtonFunc),
-		knobBindings:         make(map[Knob]KnobFunc),
-		touchBindings:        make(map[TouchButton]TouchFunc),
-		touchUpBindings:      make(map[TouchButton]TouchFunc),
 		transactionCallbacks: map[byte]transactionCallback{},
 		displays:             map[string]*Display{},
+		touches:              map[touchKey]*TouchSession{},
+		pressed:              map[Button]bool{},
+		knobs:                map[Knob]*knobState{},
+		chords: chordState{
+			modifiers:   map[Button]*Layer{},
+			triggers:    map[Button]int{},
+			held:        map[Button]*heldButton{},
+			touchTables: map[touchKey]*bindingTable{},
+		},
+		presses: pressTable{
+			behaviors: map[Button]ButtonBehavior{},
+			states:    map[Button]*pressState{},
+		},
+		actions: actionTable{
+			running: map[*ActionRun]struct{}{},
+		},
+		leds:   map[Button]color.RGBA{},
+		events: eventStream{done: make(chan struct{})},
 	}
 
 	d.SetDisplays()
@@ -63,6 +99,7 @@ func CreateDevice(s *SerialWebSockConn) *Device {
 // Close closes the connection to the Loupedeck.
 func (d *Device) Close() {
 	slog.Info("Closing connections")
+	d.stopEvents()
 	d.conn.Close()
 	d.serial.Close()
 }
@@ -179,8 +216,12 @@ func (d *Device) SetDefaultBrightness() error {
 // overridden to show the status of the Loupedeck Live's connection to
 // the host.
 func (d *Device) SetButtonColor(b Button, c color.RGBA) error {
+	d.ledMutex.Lock()
+	d.leds[b] = c
+	d.ledMutex.Unlock()
+
 	data := make([]byte, 4)
-	data[0] = byte(b)
+	data[0] = byte(d.physicalButton(b))
 	data[1] = c.R
 	data[2] = c.G
 	data[3] = c.B
@@ -188,6 +229,14 @@ func (d *Device) SetButtonColor(b Button, c color.RGBA) error {
 	return d.Send(m)
 }
 
+// ButtonColor returns the color that a Button was last set to with
+// SetButtonColor, or black.
+func (d *Device) ButtonColor(b Button) color.RGBA {
+	d.ledMutex.Lock()
+	defer d.ledMutex.Unlock()
+	return d.leds[b]
+}
+
 func (d *Device) Reset() error {
 	data := make([]byte, 0)
 	m := d.NewMessage(Reset, data)
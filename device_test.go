@@ -0,0 +1,44 @@
+package loupedeck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// newTestDevice returns a Device for product that's connected to a
+// websocket server which discards everything sent to it, so that
+// drawing and LED changes can be tested without hardware.
+func newTestDevice(t *testing.T, product string) *Device {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		for {
+			if _, _, err := c.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	d := CreateDevice(&SerialWebSockConn{Product: product})
+	d.conn = conn
+	if err := d.SetDefaultFont(); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
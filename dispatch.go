@@ -0,0 +1,195 @@
+package loupedeck
+
+import (
+	"hash/maphash"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+)
+
+// PanicFunc is a function signature used for reporting panics in
+// callbacks.  It's called with the value passed to panic and the
+// stack trace of the panicking goroutine.
+type PanicFunc func(v any, stack []byte)
+
+// controlKey identifies the control that a callback belongs to.
+// Callbacks for the same control are always run in order, even when
+// a worker pool is in use.
+type controlKey struct {
+	kind uint8
+	id   uint32
+}
+
+const (
+	buttonControl uint8 = iota + 1
+	knobControl
+	touchControl
+	sessionControl
+	regionControl
+)
+
+// workerPool runs callbacks on a fixed set of goroutines.  Each
+// control is always assigned to the same worker, so callbacks for a
+// single control run one at a time and in order, while a slow
+// callback for one control doesn't hold up the others.
+type workerPool struct {
+	seed   maphash.Seed
+	queues []chan func()
+	wg     sync.WaitGroup
+
+	// mutex is held for reading while callbacks are queued, so
+	// that stop can't close a queue that's being sent to.
+	mutex  sync.RWMutex
+	closed bool
+}
+
+// SetPanicHandler sets a function to be called when a callback
+// panics.  Panics are always recovered and logged, so a misbehaving
+// callback can't stop Listen; this allows them to be reported
+// elsewhere as well.
+func (d *Device) SetPanicHandler(f PanicFunc) {
+	d.dispatchMutex.Lock()
+	d.panicHandler = f
+	d.dispatchMutex.Unlock()
+}
+
+// UseWorkerPool runs callbacks on a pool of worker goroutines
+// instead of inside Listen.  Callbacks for the same control are
+// still run one at a time and in the order that events arrived, but
+// a slow callback for one control no longer delays the others.
+// queueLen sets how many callbacks may be waiting for each worker
+// before Listen blocks.
+//
+// Calling UseWorkerPool with 0 workers returns to running callbacks
+// inside Listen, after waiting for queued callbacks to finish.
+// Because of that wait, UseWorkerPool must not be called from a
+// callback that's running on the pool.
+func (d *Device) UseWorkerPool(workers, queueLen int) {
+	var p *workerPool
+	if workers > 0 {
+		p = &workerPool{
+			seed:   maphash.MakeSeed(),
+			queues: make([]chan func(), workers),
+		}
+		for i := range p.queues {
+			q := make(chan func(), queueLen)
+			p.queues[i] = q
+			p.wg.Add(1)
+			go func() {
+				defer p.wg.Done()
+				for f := range q {
+					f()
+				}
+			}()
+		}
+	}
+
+	d.dispatchMutex.Lock()
+	old := d.pool
+	d.pool = p
+	d.dispatchMutex.Unlock()
+
+	if old != nil {
+		old.stop()
+	}
+}
+
+// stop closes the queues and waits for the workers to finish the
+// callbacks already queued.  Callbacks submitted afterwards are
+// refused.
+func (p *workerPool) stop() {
+	p.mutex.Lock()
+	p.closed = true
+	p.mutex.Unlock()
+
+	for _, q := range p.queues {
+		close(q)
+	}
+	p.wg.Wait()
+}
+
+// submit queues f on the worker responsible for key.  It returns
+// false if the pool has been stopped.
+func (p *workerPool) submit(key controlKey, f func()) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.closed {
+		return false
+	}
+
+	var h maphash.Hash
+	h.SetSeed(p.seed)
+	h.WriteByte(key.kind)
+	h.Write([]byte{byte(key.id >> 24), byte(key.id >> 16), byte(key.id >> 8), byte(key.id)})
+	p.queues[h.Sum64()%uint64(len(p.queues))] <- f
+	return true
+}
+
+// run calls f for the control identified by key, either immediately
+// or on the worker pool.  If the pool is being replaced, f is run
+// immediately instead.  Panics in f are recovered and reported.
+func (d *Device) run(key controlKey, f func()) {
+	d.dispatchMutex.RLock()
+	p := d.pool
+	d.dispatchMutex.RUnlock()
+
+	if p == nil || !p.submit(key, func() { d.safely(f) }) {
+		d.safely(f)
+	}
+}
+
+// safely calls f, recovering and reporting any panic.
+func (d *Device) safely(f func()) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		stack := debug.Stack()
+		slog.Error("Callback panicked", "panic", v, "stack", string(stack))
+
+		d.dispatchMutex.RLock()
+		h := d.panicHandler
+		d.dispatchMutex.RUnlock()
+		if h != nil {
+			h(v, stack)
+		}
+	}()
+	f()
+}
+
+// dispatchButton calls the callbacks in t for a button press or
+// release.
+func (d *Device) dispatchButton(t *bindingTable, b Button, state ButtonState) {
+	list := &t.button
+	if state == ButtonUp {
+		list = &t.buttonUp
+	}
+	for _, f := range list.get(b) {
+		f := f
+		d.run(controlKey{buttonControl, uint32(b)}, func() { f(b, state) })
+	}
+}
+
+// knobCalls binds each KnobFunc to a knob turn.
+func knobCalls(fs []KnobFunc, k Knob, v int) []func() {
+	calls := make([]func(), len(fs))
+	for i, f := range fs {
+		f := f
+		calls[i] = func() { f(k, v) }
+	}
+	return calls
+}
+
+// dispatchTouch calls the callbacks in t for a TouchButton press or
+// release.
+func (d *Device) dispatchTouch(t *bindingTable, b TouchButton, state ButtonState, x, y uint16) {
+	list := &t.touch
+	if state == ButtonUp {
+		list = &t.touchUp
+	}
+	for _, f := range list.get(b) {
+		f := f
+		d.run(controlKey{touchControl, uint32(b)}, func() { f(b, state, x, y) })
+	}
+}
@@ -0,0 +1,80 @@
+package loupedeck
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWorkerPoolOrder(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	d.UseWorkerPool(4, 1)
+
+	var mutex sync.Mutex
+	got := map[Button][]int{}
+	buttons := []Button{Button0, Button1, Button2, Button3}
+	for i := 0; i < 100; i++ {
+		for _, b := range buttons {
+			b, i := b, i
+			d.run(controlKey{buttonControl, uint32(b)}, func() {
+				mutex.Lock()
+				got[b] = append(got[b], i)
+				mutex.Unlock()
+			})
+		}
+	}
+	d.UseWorkerPool(0, 0)
+
+	for _, b := range buttons {
+		if len(got[b]) != 100 {
+			t.Errorf("%v: %d callbacks ran, want 100", b, len(got[b]))
+			continue
+		}
+		for i, v := range got[b] {
+			if v != i {
+				t.Errorf("%v: callback %d ran in position %d", b, v, i)
+				break
+			}
+		}
+	}
+}
+
+func TestWorkerPoolSwap(t *testing.T) {
+	// Replacing the pool while callbacks are being queued mustn't
+	// lose any of them, or send on a stopped pool's queues.
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	d.UseWorkerPool(2, 0)
+
+	var mutex sync.Mutex
+	count := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 2000; i++ {
+			d.run(controlKey{knobControl, uint32(i % 6)}, func() {
+				mutex.Lock()
+				count++
+				mutex.Unlock()
+			})
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		d.UseWorkerPool(1+i%3, i%2)
+	}
+	<-done
+	d.UseWorkerPool(0, 0)
+
+	if count != 2000 {
+		t.Errorf("%d callbacks ran, want 2000", count)
+	}
+}
+
+func TestSafely(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	var got any
+	d.SetPanicHandler(func(v any, stack []byte) { got = v })
+
+	d.run(controlKey{buttonControl, 1}, func() { panic("boom") })
+	if got != "boom" {
+		t.Errorf("panic handler got %v, want boom", got)
+	}
+}
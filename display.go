@@ -19,9 +19,21 @@ type Display struct {
 	offsety   int // used for mapping legacy left/center/right screens onto unified devices.
 	Name      string
 	bigEndian bool
+	regions   []*TouchRegion
 }
 
+// GetDisplay returns the named display.  When the device's
+// orientation puts its physical right side on the user's left,
+// "left" and "right" refer to the displays as the user sees them.
 func (d *Device) GetDisplay(name string) *Display {
+	if d.sidesSwapped() {
+		switch name {
+		case "left":
+			name = "right"
+		case "right":
+			name = "left"
+		}
+	}
 	return d.displays[name]
 }
 
@@ -38,53 +50,63 @@ func (d *Device) addDisplay(name string, id byte, width, height, offsetx, offset
 	}
 }
 
+// SetDisplays configures the displays and touch key layout for
+// the connected model.
 func (d *Device) SetDisplays() {
-	switch d.Product {
-	case "0003":
-		slog.Info("Using Loupedeck CT v1 display settings.")
-		d.addDisplay("left", 'L', 60, 270, 0, 0, false)
-		d.addDisplay("main", 'A', 360, 270, 60, 0, false)
-		d.addDisplay("right", 'R', 60, 270, 420, 0, false)
-		d.addDisplay("dial", 'W', 240, 240, 0, 0, true)
-	case "0007":
-		slog.Info("Using Loupedeck CT v2 display settings.")
-		d.addDisplay("left", 'M', 60, 270, 0, 0, false)
-		d.addDisplay("main", 'M', 360, 270, 60, 0, false)
-		d.addDisplay("right", 'M', 60, 270, 420, 0, false)
-		d.addDisplay("all", 'M', 480, 270, 0, 0, false)
-		d.addDisplay("dial", 'W', 240, 240, 0, 0, true)
-	case "0004":
-		slog.Info("Using Loupedeck Live display settings.")
-		d.addDisplay("left", 'M', 60, 270, 0, 0, false)
-		d.addDisplay("main", 'M', 360, 270, 60, 0, false)
-		d.addDisplay("right", 'M', 60, 270, 420, 0, false)
-		d.addDisplay("all", 'M', 480, 270, 0, 0, false)
-	case "0006", "0d06":
-		slog.Info("Using Loupedeck Live S/Razor Stream Controller display settings.")
-		d.addDisplay("left", 'M', 60, 270, 0, 0, false)
-		d.addDisplay("main", 'M', 360, 270, 60, 0, false)
-		d.addDisplay("right", 'M', 60, 270, 420, 0, false)
-		d.addDisplay("all", 'M', 480, 270, 0, 0, false)
-	default:
+	m, ok := models[d.Product]
+	if !ok {
 		panic("Unknown device type: " + d.Product)
 	}
+	slog.Info("Using display settings", "model", m.name)
+
+	d.model = m
+	d.Model = m.name
+	d.displays = map[string]*Display{}
+	for _, s := range m.displays {
+		d.addDisplay(s.name, s.id, s.width, s.height, s.offsetx, s.offsety, s.bigEndian)
+	}
+	d.updateLayout()
 }
 
+// logicalRect returns the area covered by the display, in
+// touchscreen coordinates as seen in the device's current
+// orientation.
+func (d *Display) logicalRect() image.Rectangle {
+	r := image.Rect(d.offsetx, d.offsety, d.offsetx+d.width, d.offsety+d.height)
+	if d.Name == "dial" {
+		return d.device.orientation.RectToLogical(r, d.width, d.height)
+	}
+	w, h := d.device.surfaceSize()
+	return d.device.orientation.RectToLogical(r, w, h)
+}
+
+// Height returns the height of the display, as seen in the device's
+// current orientation.
 func (d *Display) Height() int {
-	return d.height
+	_, h := d.device.orientation.Size(d.width, d.height)
+	return h
 }
 
+// Width returns the width of the display, as seen in the device's
+// current orientation.
 func (d *Display) Width() int {
-	return d.width
+	w, _ := d.device.orientation.Size(d.width, d.height)
+	return w
 }
 
 func (d *Display) Draw(im image.Image, xoff, yoff int) {
 	slog.Info("Draw called", "Display", d.Name, "xoff", xoff, "yoff", yoff, "width", im.Bounds().Dx(), "height", im.Bounds().Dy())
 
-	x := xoff + d.offsetx
-	y := yoff + d.offsety
-	width := im.Bounds().Dx()
-	height := im.Bounds().Dy()
+	// Rotate and/or mirror the image to match the way that the
+	// device is mounted.  This maps the image onto the display's
+	// physical coordinates.
+	r := image.Rect(xoff, yoff, xoff+im.Bounds().Dx(), yoff+im.Bounds().Dy())
+	im, r = d.device.orientation.transformImage(im, r, d.width, d.height)
+
+	x := r.Min.X + d.offsetx
+	y := r.Min.Y + d.offsety
+	width := r.Dx()
+	height := r.Dy()
 	slog.Info("Draw parameters", "x", x, "y", y, "width", width, "height", height)
 
 	// Call 'WriteFramebuff'
@@ -140,8 +162,8 @@ func (d *Display) Draw(im image.Image, xoff, yoff int) {
 }
 
 func (d *Display) Clear() {
-	dw := d.width
-	dh := d.height
+	dw := d.Width()
+	dh := d.Height()
 	im := image.NewRGBA(image.Rect(0, 0, dw, dh))
 	draw.Draw(im, im.Bounds(), &image.Uniform{&color.Black}, image.ZP, draw.Src)
 	d.Draw(im, 0, 0)
@@ -1,8 +1,17 @@
 package loupedeck
 
+// DragEvent describes the stage of a drag in a GestureEvent.
 type DragEvent uint16
 
 const (
+	// DragClick marks a touch that ended without becoming a
+	// drag, i.e. a tap.
 	DragClick DragEvent = 1
-	DragDone  DragEvent = 2
+	// DragDone is sent when the finger is lifted at the end of a drag.
+	DragDone DragEvent = 2
+	// DragStart is sent when a finger first moves far enough to
+	// count as a drag.
+	DragStart DragEvent = 3
+	// DragMove is sent for each further movement in a drag.
+	DragMove DragEvent = 4
 )
@@ -0,0 +1,248 @@
+package loupedeck
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Event is an input or connection event from a Loupedeck, delivered
+// through the channel returned by Device.Events.  It is implemented
+// by ButtonEvent, KnobEvent, TouchEvent, and ConnectionEvent; use a
+// type switch to tell them apart.
+type Event interface {
+	// Info returns the time and source of the event.
+	Info() EventInfo
+	isEvent()
+}
+
+// EventInfo holds the fields common to all Events.
+type EventInfo struct {
+	// Time is when the event was received from the device.
+	Time time.Time
+	// Device is the device that sent the event.
+	Device *Device
+	// SerialNo is the serial number of the device, for telling
+	// apart events from several devices on one channel.
+	SerialNo string
+}
+
+// Info returns the time and source of the event.
+func (e EventInfo) Info() EventInfo { return e }
+
+// isEvent is defined on each event type, rather than on EventInfo,
+// so that embedding EventInfo doesn't make other types Events.
+func (ButtonEvent) isEvent()     {}
+func (KnobEvent) isEvent()       {}
+func (TouchEvent) isEvent()      {}
+func (ConnectionEvent) isEvent() {}
+
+// ButtonEvent is sent when a Button is pressed or released.
+type ButtonEvent struct {
+	EventInfo
+	Button Button
+	State  ButtonState
+}
+
+// KnobEvent is sent when a Knob is turned.
+type KnobEvent struct {
+	EventInfo
+	Knob Knob
+	// Delta is the number of ticks turned, after acceleration;
+	// positive values are clockwise.
+	Delta int
+	// RawDelta is the number of ticks reported by the device.
+	RawDelta int
+}
+
+// TouchEvent is sent when a finger touches, moves on, or leaves
+// the touchscreen.
+type TouchEvent struct {
+	EventInfo
+	// Button is the TouchButton under the finger.
+	Button TouchButton
+	Phase  TouchPhase
+	// X and Y are in touchscreen coordinates, as seen in the
+	// device's current orientation.
+	X, Y    uint16
+	Session TouchSession
+}
+
+// ConnectionEvent is sent when Listen starts, and again when it
+// stops.  After the disconnect event the channel is closed.
+type ConnectionEvent struct {
+	EventInfo
+	Connected bool
+	// Err is the error that ended the connection, if any.
+	Err error
+}
+
+// DropPolicy controls what happens when an event channel's buffer
+// is full.
+type DropPolicy uint8
+
+const (
+	// DropNewest discards the new event, keeping the buffer as-is.
+	DropNewest DropPolicy = 0
+	// DropOldest discards the oldest buffered event to make room
+	// for the new one.
+	DropOldest DropPolicy = 1
+	// Block waits for the reader to make room.  This stalls all
+	// input processing until the reader catches up, or until the
+	// device is closed.
+	Block DropPolicy = 2
+)
+
+// EventConfig configures an event channel.
+type EventConfig struct {
+	// Buffer is the capacity of the channel.
+	Buffer int
+	// Policy controls what happens when the buffer is full.
+	Policy DropPolicy
+}
+
+// DefaultEventConfig is used by Device.Events.
+var DefaultEventConfig = EventConfig{
+	Buffer: 64,
+	Policy: DropOldest,
+}
+
+// eventSubscriber is a single channel returned by Events.
+type eventSubscriber struct {
+	ch      chan Event
+	config  EventConfig
+	dropped int
+}
+
+// eventStream fans events out to all subscribers.  Events are only
+// published by Listen, so sends never race with the channels being
+// closed.
+type eventStream struct {
+	mutex       sync.Mutex
+	subscribers []*eventSubscriber
+	// done is closed by Device.Close, so that sends waiting on a
+	// reader with the Block policy give up.
+	done     chan struct{}
+	stopOnce sync.Once
+	// closed is set once Listen has returned and the channels
+	// have been closed.
+	closed bool
+}
+
+// Events returns a channel of input events from the device, using
+// DefaultEventConfig.  Events are sent on the channel in addition to
+// any callbacks set with BindButton and friends.  Each call returns
+// a new channel that receives every event; all channels are closed
+// when Listen returns.  Channels returned after that are already
+// closed.
+func (d *Device) Events() <-chan Event {
+	return d.EventsWithConfig(DefaultEventConfig)
+}
+
+// EventsWithConfig is like Events, but with a custom buffer size and
+// drop policy.
+func (d *Device) EventsWithConfig(c EventConfig) <-chan Event {
+	s := &eventSubscriber{
+		ch:     make(chan Event, max(c.Buffer, 0)),
+		config: c,
+	}
+	d.events.mutex.Lock()
+	defer d.events.mutex.Unlock()
+	if d.events.closed {
+		close(s.ch)
+		return s.ch
+	}
+	d.events.subscribers = append(d.events.subscribers, s)
+	return s.ch
+}
+
+// eventInfo returns an EventInfo for an event received now.
+func (d *Device) eventInfo() EventInfo {
+	return EventInfo{
+		Time:     time.Now(),
+		Device:   d,
+		SerialNo: d.SerialNo,
+	}
+}
+
+// subscribers returns the current subscribers.  The mutex isn't held
+// while sending, so that a blocked reader doesn't hold up Events.
+func (d *Device) subscribers() []*eventSubscriber {
+	d.events.mutex.Lock()
+	defer d.events.mutex.Unlock()
+	return slices.Clone(d.events.subscribers)
+}
+
+// publish sends an event to every subscriber.
+func (d *Device) publish(e Event) {
+	for _, s := range d.subscribers() {
+		s.send(e, s.config.Policy, d.events.done)
+	}
+}
+
+// stopEvents makes sends that are waiting on a reader give up.
+func (d *Device) stopEvents() {
+	d.events.stopOnce.Do(func() { close(d.events.done) })
+}
+
+func (s *eventSubscriber) send(e Event, policy DropPolicy, done <-chan struct{}) {
+	switch policy {
+	case Block:
+		select {
+		case s.ch <- e:
+		case <-done:
+			s.drop()
+		}
+		return
+	case DropOldest:
+		// Make room by discarding the oldest event.  If the
+		// reader keeps the channel full (or it's unbuffered),
+		// give up and drop the new event instead.
+		for i := 0; i < 2; i++ {
+			select {
+			case s.ch <- e:
+				return
+			default:
+			}
+			select {
+			case <-s.ch:
+				s.drop()
+			default:
+			}
+		}
+		s.drop()
+	default:
+		select {
+		case s.ch <- e:
+		default:
+			s.drop()
+		}
+	}
+}
+
+func (s *eventSubscriber) drop() {
+	s.dropped++
+	if s.dropped == 1 || s.dropped%100 == 0 {
+		slog.Warn("Event channel full, dropping events", "dropped", s.dropped)
+	}
+}
+
+// closeEvents sends a final ConnectionEvent and closes all event
+// channels.  The final event never blocks, even for subscribers using
+// the Block policy; older events are dropped to make room for it
+// instead.
+func (d *Device) closeEvents(err error) {
+	e := ConnectionEvent{EventInfo: d.eventInfo(), Connected: false, Err: err}
+	for _, s := range d.subscribers() {
+		s.send(e, DropOldest, d.events.done)
+	}
+
+	d.events.mutex.Lock()
+	defer d.events.mutex.Unlock()
+	for _, s := range d.events.subscribers {
+		close(s.ch)
+	}
+	d.events.subscribers = nil
+	d.events.closed = true
+}
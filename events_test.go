@@ -0,0 +1,84 @@
+package loupedeck
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventPolicies(t *testing.T) {
+	tests := []struct {
+		policy DropPolicy
+		want   []Button
+	}{
+		{DropNewest, []Button{Button1, Button2}},
+		{DropOldest, []Button{Button3, Button4}},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		ch := d.EventsWithConfig(EventConfig{Buffer: 2, Policy: tt.policy})
+		for _, b := range []Button{Button1, Button2, Button3, Button4} {
+			d.publish(ButtonEvent{Button: b})
+		}
+		for i, want := range tt.want {
+			if e := (<-ch).(ButtonEvent); e.Button != want {
+				t.Errorf("policy %d: event %d is %v, want %v", tt.policy, i, e.Button, want)
+			}
+		}
+	}
+}
+
+func TestBlockedEventsStop(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	ch := d.EventsWithConfig(EventConfig{Buffer: 1, Policy: Block})
+	d.publish(ButtonEvent{Button: Button1})
+
+	published := make(chan struct{})
+	go func() {
+		d.publish(ButtonEvent{Button: Button2})
+		close(published)
+	}()
+
+	// Other subscribers can still be added while a send is
+	// blocked.
+	d.Events()
+
+	select {
+	case <-published:
+		t.Fatal("publish didn't wait for the reader")
+	case <-time.After(10 * time.Millisecond):
+	}
+	d.stopEvents()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("publish still blocked after stopEvents")
+	}
+
+	d.closeEvents(nil)
+	var got []Event
+	for e := range ch {
+		got = append(got, e)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want 1", len(got))
+	}
+	if e, ok := got[0].(ConnectionEvent); !ok || e.Connected {
+		t.Errorf("last event is %#v, want a disconnect", got[0])
+	}
+}
+
+func TestEventsAfterClose(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	d.closeEvents(nil)
+	for _, policy := range []DropPolicy{DropNewest, DropOldest, Block} {
+		ch := d.EventsWithConfig(EventConfig{Buffer: 1, Policy: policy})
+		select {
+		case e, ok := <-ch:
+			if ok {
+				t.Errorf("policy %d: got event %#v from a closed device, want a closed channel", policy, e)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("policy %d: channel isn't closed", policy)
+		}
+	}
+}
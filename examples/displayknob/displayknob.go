@@ -13,46 +13,51 @@ package main
 
 import (
 	"fmt"
+	"image/color"
 
 	"github.com/scottlaird/loupedeck"
 )
 
 func main() {
-	l, err := loupedeck.ConnectAuto()
+	c, err := loupedeck.ConnectSerialAuto()
 	if err != nil {
 		panic(err)
 	}
+	l := loupedeck.CreateDevice(c)
+	if err := loupedeck.ConnectWebsocket(c, l); err != nil {
+		panic(err)
+	}
 	defer l.Close()
 
 	go l.Listen()
-	l.SetDisplays()
 
-	// Create widgets
-	x1 := loupedeck.NewWatchedInt(50)
-	w1 := loupedeck.NewDKAnalogWidget(0, 100, x1, "AnalogTest")
+	// Create widgets, and attach each to a knob.
+	widgets := loupedeck.NewWidgetContainer(l)
+	yellow := color.RGBA{255, 200, 0, 255}
 
+	x1 := loupedeck.NewWatched(50, 0, 100, 1)
 	x1.AddWatcher(func(i int) {
 		fmt.Printf("Knob (1) set to %d\n", i)
-		w1.Draw(l)
 	})
+	w1 := loupedeck.NewAnalogGauge("AnalogTest", x1, yellow, color.Black)
+	widgets.Add(loupedeck.Touch1, w1)
+	widgets.AttachKnob(loupedeck.Knob1, w1)
 
-	x2 := loupedeck.NewWatchedInt(10)
-	w2 := loupedeck.NewDKAnalogWidget(0, 30, x2, "Test 2")
-
+	x2 := loupedeck.NewWatched(10, 0, 30, 1)
 	x2.AddWatcher(func(i int) {
 		fmt.Printf("Knob (2) set to %d\n", i)
-		w2.Draw(l)
 	})
+	w2 := loupedeck.NewAnalogGauge("Test 2", x2, yellow, color.Black)
+	widgets.Add(loupedeck.Touch5, w2)
+	widgets.AttachKnob(loupedeck.Knob2, w2)
 
-	x3 := loupedeck.NewWatchedInt(70)
-	w3 := loupedeck.NewDKAnalogWidget(0, 100, x3, "Test 3")
-
+	x3 := loupedeck.NewWatched(70, 0, 100, 1)
 	x3.AddWatcher(func(i int) {
 		fmt.Printf("Knob (3) set to %d\n", i)
-		w3.Draw(l)
 	})
-
-	l.WidgetHolder([]loupedeck.DKWidget{w1, w2, w3})
+	w3 := loupedeck.NewAnalogGauge("Test 3", x3, yellow, color.Black)
+	widgets.Add(loupedeck.Touch9, w3)
+	widgets.AttachKnob(loupedeck.Knob3, w3)
 
 	select {} // Wait forever
 
@@ -21,14 +21,17 @@ import (
 )
 
 func main() {
-	l, err := loupedeck.ConnectAuto()
+	c, err := loupedeck.ConnectSerialAuto()
 	if err != nil {
 		panic(err)
 	}
+	l := loupedeck.CreateDevice(c)
+	if err := loupedeck.ConnectWebsocket(c, l); err != nil {
+		panic(err)
+	}
 	defer l.Close()
 
 	go l.Listen()
-	l.SetDisplays()
 
 	d := l.GetDisplay("dial")
 
@@ -0,0 +1,326 @@
+package loupedeck
+
+import (
+	"image"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// GestureType identifies the kind of gesture in a GestureEvent.
+type GestureType uint8
+
+const (
+	// GestureTap is a short touch that doesn't move.
+	GestureTap GestureType = 1
+	// GestureDoubleTap is two taps in quick succession.
+	GestureDoubleTap GestureType = 2
+	// GestureLongPress is a touch that is held without moving.
+	// It's sent while the finger is still down.
+	GestureLongPress GestureType = 3
+	// GestureDrag is sent repeatedly as a finger moves.
+	GestureDrag GestureType = 4
+	// GestureSwipe is a quick drag in one direction.  It's sent
+	// when the finger is lifted, after the final GestureDrag.
+	GestureSwipe GestureType = 5
+)
+
+// SwipeDirection is the direction of a GestureSwipe, as seen in the
+// device's current orientation.
+type SwipeDirection uint8
+
+const (
+	SwipeLeft  SwipeDirection = 1
+	SwipeRight SwipeDirection = 2
+	SwipeUp    SwipeDirection = 3
+	SwipeDown  SwipeDirection = 4
+)
+
+// GestureEvent describes a gesture recognized on a TouchRegion.
+type GestureEvent struct {
+	Type   GestureType
+	Region *TouchRegion
+	// Session is the touch that completed the gesture.
+	Session TouchSession
+	// X and Y are the finger's position relative to the top-left
+	// corner of the region.
+	X, Y int
+	// Drag is DragStart, DragMove or DragDone for GestureDrag,
+	// and DragClick for GestureTap and GestureDoubleTap.
+	Drag DragEvent
+	// DX and DY are the movement since the previous GestureDrag
+	// for this touch, or the total movement for GestureSwipe.
+	DX, DY int
+	// Direction is set for GestureSwipe.
+	Direction SwipeDirection
+}
+
+// GestureFunc is a function signature used for callbacks on
+// gestures.  Taps delayed by double-tap detection and long-presses
+// are delivered from a timer, not from Listen.
+type GestureFunc func(GestureEvent)
+
+// GestureConfig holds the thresholds used to tell gestures apart.
+type GestureConfig struct {
+	// TapSlop is how far, in pixels, a finger may move and still
+	// count as a tap or long-press rather than a drag.
+	TapSlop int
+	// TapMaxDuration is the longest touch that counts as a tap.
+	TapMaxDuration time.Duration
+	// DoubleTapWindow is how long to wait for a second tap.  When
+	// it's non-zero, single taps are delayed by this long; set it
+	// to zero to disable double-tap detection and send taps
+	// immediately.
+	DoubleTapWindow time.Duration
+	// LongPressDelay is how long a finger must be held still for
+	// a long-press.  Zero disables long-press detection.
+	LongPressDelay time.Duration
+	// SwipeMinDistance is the shortest drag, in pixels, that
+	// counts as a swipe.
+	SwipeMinDistance int
+	// SwipeMaxDuration is the longest drag that counts as a swipe.
+	SwipeMaxDuration time.Duration
+}
+
+// DefaultGestureConfig is used by OnGesture until SetGestureConfig
+// is called.
+var DefaultGestureConfig = GestureConfig{
+	TapSlop:          20,
+	TapMaxDuration:   500 * time.Millisecond,
+	DoubleTapWindow:  300 * time.Millisecond,
+	LongPressDelay:   600 * time.Millisecond,
+	SwipeMinDistance: 40,
+	SwipeMaxDuration: 400 * time.Millisecond,
+}
+
+// isClick decides if a touch is a tap or the start of a drag.
+func (c GestureConfig) isClick(duration time.Duration, x, y int) bool {
+	if duration > c.TapMaxDuration {
+		return false
+	}
+	return !c.beyondSlop(x, y)
+}
+
+func (c GestureConfig) beyondSlop(x, y int) bool {
+	return x > c.TapSlop || x < -c.TapSlop || y > c.TapSlop || y < -c.TapSlop
+}
+
+// gestureRecognizer holds the gesture state for a single TouchRegion.
+type gestureRecognizer struct {
+	mutex   sync.Mutex
+	config  GestureConfig
+	f       GestureFunc
+	touches map[byte]*gestureTouch
+	// pendingTap is a tap waiting to see if it becomes a double-tap.
+	pendingTap *time.Timer
+}
+
+// gestureTouch tracks the gesture state of a single finger.
+type gestureTouch struct {
+	session     TouchSession
+	last        image.Point
+	dragging    bool
+	longPressed bool
+	longPress   *time.Timer
+}
+
+// OnGesture sets a callback for gestures on the region.
+func (r *TouchRegion) OnGesture(f GestureFunc) {
+	r.display.device.regionMutex.Lock()
+	if r.gestures == nil {
+		r.gestures = &gestureRecognizer{
+			config:  DefaultGestureConfig,
+			touches: map[byte]*gestureTouch{},
+		}
+	}
+	g := r.gestures
+	r.display.device.regionMutex.Unlock()
+
+	g.mutex.Lock()
+	g.f = f
+	g.mutex.Unlock()
+}
+
+// SetGestureConfig changes the thresholds used to recognize gestures
+// on the region.  It must be called after OnGesture.
+func (r *TouchRegion) SetGestureConfig(c GestureConfig) {
+	g := r.recognizer()
+	if g == nil {
+		slog.Warn("SetGestureConfig called before OnGesture", "region", r.Name)
+		return
+	}
+	g.mutex.Lock()
+	g.config = c
+	g.mutex.Unlock()
+}
+
+// recognizer returns the region's gesture recognizer, or nil if
+// OnGesture hasn't been called.
+func (r *TouchRegion) recognizer() *gestureRecognizer {
+	r.display.device.regionMutex.RLock()
+	defer r.display.device.regionMutex.RUnlock()
+	return r.gestures
+}
+
+// recognize feeds a touch into the region's gesture recognizer.
+func (r *TouchRegion) recognize(phase TouchPhase, s TouchSession) {
+	g := r.recognizer()
+	if g == nil {
+		return
+	}
+
+	var events []GestureEvent
+	emit := func(t GestureType, s TouchSession) *GestureEvent {
+		p := r.local(s.Position).Sub(r.bounds.Min)
+		events = append(events, GestureEvent{Type: t, Region: r, Session: s, X: p.X, Y: p.Y})
+		return &events[len(events)-1]
+	}
+
+	g.mutex.Lock()
+	switch phase {
+	case TouchPhaseBegin:
+		t := &gestureTouch{session: s, last: s.Position}
+		g.touches[s.ID] = t
+		if g.config.LongPressDelay > 0 {
+			t.longPress = time.AfterFunc(g.config.LongPressDelay, func() {
+				r.longPress(t)
+			})
+		}
+
+	case TouchPhaseMove:
+		t := g.touches[s.ID]
+		if t == nil {
+			break
+		}
+		t.session = s
+		delta := s.Delta()
+		if !t.dragging && !t.longPressed && g.config.beyondSlop(delta.X, delta.Y) {
+			t.dragging = true
+			t.stopLongPress()
+			e := emit(GestureDrag, s)
+			e.Drag = DragStart
+			e.DX, e.DY = delta.X, delta.Y
+		} else if t.dragging {
+			e := emit(GestureDrag, s)
+			e.Drag = DragMove
+			e.DX, e.DY = s.Position.X-t.last.X, s.Position.Y-t.last.Y
+		}
+		t.last = s.Position
+
+	case TouchPhaseEnd:
+		t := g.touches[s.ID]
+		if t == nil {
+			break
+		}
+		delete(g.touches, s.ID)
+		t.session = s
+		t.stopLongPress()
+		delta := s.Delta()
+
+		switch {
+		case t.longPressed:
+			// Already handled while the finger was down.
+
+		case t.dragging:
+			e := emit(GestureDrag, s)
+			e.Drag = DragDone
+			e.DX, e.DY = s.Position.X-t.last.X, s.Position.Y-t.last.Y
+			if dir, ok := g.config.swipe(s.Duration(), delta); ok {
+				e := emit(GestureSwipe, s)
+				e.DX, e.DY = delta.X, delta.Y
+				e.Direction = dir
+			}
+
+		case g.config.isClick(s.Duration(), delta.X, delta.Y):
+			if g.pendingTap != nil && g.pendingTap.Stop() {
+				g.pendingTap = nil
+				e := emit(GestureDoubleTap, s)
+				e.Drag = DragClick
+			} else if g.config.DoubleTapWindow > 0 {
+				g.pendingTap = time.AfterFunc(g.config.DoubleTapWindow, func() {
+					r.delayedTap(s)
+				})
+			} else {
+				e := emit(GestureTap, s)
+				e.Drag = DragClick
+			}
+		}
+	}
+	f := g.f
+	g.mutex.Unlock()
+
+	r.emitGestures(f, events)
+}
+
+// longPress is called from a timer when a finger has been held long
+// enough to count as a long-press.
+func (r *TouchRegion) longPress(t *gestureTouch) {
+	g := r.gestures
+	g.mutex.Lock()
+	if t.dragging || g.touches[t.session.ID] != t {
+		g.mutex.Unlock()
+		return
+	}
+	t.longPressed = true
+	s := t.session
+	s.LastTime = time.Now()
+	f := g.f
+	g.mutex.Unlock()
+
+	p := r.local(s.Position).Sub(r.bounds.Min)
+	r.emitGestures(f, []GestureEvent{{Type: GestureLongPress, Region: r, Session: s, X: p.X, Y: p.Y}})
+}
+
+// delayedTap is called from a timer when no second tap arrived
+// within the double-tap window.
+func (r *TouchRegion) delayedTap(s TouchSession) {
+	g := r.gestures
+	g.mutex.Lock()
+	g.pendingTap = nil
+	f := g.f
+	g.mutex.Unlock()
+
+	p := r.local(s.Position).Sub(r.bounds.Min)
+	r.emitGestures(f, []GestureEvent{{Type: GestureTap, Region: r, Session: s, X: p.X, Y: p.Y, Drag: DragClick}})
+}
+
+func (r *TouchRegion) emitGestures(f GestureFunc, events []GestureEvent) {
+	if f == nil {
+		return
+	}
+	for _, e := range events {
+		e := e
+		slog.Info("Gesture", "region", r.Name, "type", e.Type, "x", e.X, "y", e.Y)
+		r.display.device.run(r.key(), func() { f(e) })
+	}
+}
+
+func (t *gestureTouch) stopLongPress() {
+	if t.longPress != nil {
+		t.longPress.Stop()
+	}
+}
+
+// swipe decides if a drag was quick and long enough to be a swipe,
+// and if so in which direction.
+func (c GestureConfig) swipe(duration time.Duration, delta image.Point) (SwipeDirection, bool) {
+	if duration > c.SwipeMaxDuration {
+		return 0, false
+	}
+
+	dx, dy := delta.X, delta.Y
+	adx, ady := max(dx, -dx), max(dy, -dy)
+	switch {
+	case adx >= ady && adx >= c.SwipeMinDistance:
+		if dx < 0 {
+			return SwipeLeft, true
+		}
+		return SwipeRight, true
+	case ady > adx && ady >= c.SwipeMinDistance:
+		if dy < 0 {
+			return SwipeUp, true
+		}
+		return SwipeDown, true
+	}
+	return 0, false
+}
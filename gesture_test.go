@@ -0,0 +1,208 @@
+package loupedeck
+
+import (
+	"image"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// touchStep is one touch message in a gesture test: a phase, how long
+// after the touch started it arrives, and where the finger is, in
+// "main" display coordinates.
+type touchStep struct {
+	phase TouchPhase
+	at    time.Duration
+	x, y  int
+}
+
+// gestureRecorder collects the gestures sent to a region.
+type gestureRecorder struct {
+	mutex  sync.Mutex
+	events []GestureEvent
+}
+
+func (g *gestureRecorder) record(e GestureEvent) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.events = append(g.events, e)
+}
+
+// summary describes the recorded gestures as strings, such as "tap"
+// or "drag 3", for comparison.
+func (g *gestureRecorder) summary() []string {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	var s []string
+	for _, e := range g.events {
+		switch e.Type {
+		case GestureTap:
+			s = append(s, "tap")
+		case GestureDoubleTap:
+			s = append(s, "double-tap")
+		case GestureLongPress:
+			s = append(s, "long-press")
+		case GestureDrag:
+			s = append(s, [...]string{DragStart: "drag-start", DragMove: "drag-move", DragDone: "drag-done"}[e.Drag])
+		case GestureSwipe:
+			s = append(s, [...]string{SwipeLeft: "swipe-left", SwipeRight: "swipe-right", SwipeUp: "swipe-up", SwipeDown: "swipe-down"}[e.Direction])
+		}
+	}
+	return s
+}
+
+// newGestureRegion returns a region covering the "main" display,
+// recording its gestures.
+func newGestureRegion(t *testing.T, c GestureConfig) (*Device, *TouchRegion, *gestureRecorder) {
+	t.Helper()
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	disp := d.GetDisplay("main")
+	r := disp.AddTouchRegion("test", image.Rect(0, 0, disp.Width(), disp.Height()), nil)
+	g := &gestureRecorder{}
+	r.OnGesture(g.record)
+	r.SetGestureConfig(c)
+	return d, r, g
+}
+
+// playTouch feeds a single finger's steps into a region, starting at
+// start.  If realTime is set, it sleeps until each step is due, so
+// that the recognizer's timers can fire.
+func playTouch(d *Device, r *TouchRegion, id byte, start time.Time, steps []touchStep, realTime bool) {
+	origin := d.GetDisplay("main").logicalRect().Min
+	for _, step := range steps {
+		if realTime {
+			time.Sleep(time.Until(start.Add(step.at)))
+		}
+		now := start.Add(step.at)
+		p := origin.Add(image.Pt(step.x, step.y))
+		var s *TouchSession
+		if step.phase == TouchPhaseEnd {
+			s = d.endTouch(id, Touch1, p, now)
+		} else {
+			s, _ = d.trackTouch(id, Touch1, p, now)
+		}
+		r.recognize(step.phase, *s)
+	}
+}
+
+func TestGestures(t *testing.T) {
+	c := DefaultGestureConfig
+	c.DoubleTapWindow = 0
+	c.LongPressDelay = 0
+	ms := time.Millisecond
+
+	tests := []struct {
+		name  string
+		steps []touchStep
+		want  []string
+	}{
+		{
+			name:  "tap",
+			steps: []touchStep{{TouchPhaseBegin, 0, 100, 100}, {TouchPhaseEnd, 100 * ms, 100, 100}},
+			want:  []string{"tap"},
+		},
+		{
+			name:  "tap with a little movement",
+			steps: []touchStep{{TouchPhaseBegin, 0, 100, 100}, {TouchPhaseMove, 50 * ms, 110, 95}, {TouchPhaseEnd, 100 * ms, 115, 90}},
+			want:  []string{"tap"},
+		},
+		{
+			name:  "held too long for a tap",
+			steps: []touchStep{{TouchPhaseBegin, 0, 100, 100}, {TouchPhaseEnd, 700 * ms, 100, 100}},
+			want:  nil,
+		},
+		{
+			name: "slow drag",
+			steps: []touchStep{
+				{TouchPhaseBegin, 0, 100, 100},
+				{TouchPhaseMove, 100 * ms, 130, 100},
+				{TouchPhaseMove, 300 * ms, 160, 100},
+				{TouchPhaseEnd, 600 * ms, 170, 100},
+			},
+			want: []string{"drag-start", "drag-move", "drag-done"},
+		},
+		{
+			name: "swipe left",
+			steps: []touchStep{
+				{TouchPhaseBegin, 0, 200, 100},
+				{TouchPhaseMove, 50 * ms, 150, 100},
+				{TouchPhaseEnd, 100 * ms, 100, 110},
+			},
+			want: []string{"drag-start", "drag-done", "swipe-left"},
+		},
+		{
+			name: "swipe down",
+			steps: []touchStep{
+				{TouchPhaseBegin, 0, 100, 50},
+				{TouchPhaseMove, 50 * ms, 100, 100},
+				{TouchPhaseEnd, 100 * ms, 90, 150},
+			},
+			want: []string{"drag-start", "drag-done", "swipe-down"},
+		},
+		{
+			name: "too short for a swipe",
+			steps: []touchStep{
+				{TouchPhaseBegin, 0, 100, 100},
+				{TouchPhaseMove, 50 * ms, 125, 100},
+				{TouchPhaseEnd, 100 * ms, 130, 100},
+			},
+			want: []string{"drag-start", "drag-done"},
+		},
+	}
+	for _, tt := range tests {
+		d, r, g := newGestureRegion(t, c)
+		playTouch(d, r, 1, time.Now(), tt.steps, false)
+		if got := g.summary(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGestureTimers(t *testing.T) {
+	c := DefaultGestureConfig
+	c.DoubleTapWindow = 100 * time.Millisecond
+	c.LongPressDelay = 50 * time.Millisecond
+	ms := time.Millisecond
+	tap := []touchStep{{TouchPhaseBegin, 0, 100, 100}, {TouchPhaseEnd, 10 * ms, 100, 100}}
+
+	tests := []struct {
+		name string
+		// touches start at the given offsets.
+		touches []time.Duration
+		steps   []touchStep
+		want    []string
+	}{
+		{"single tap is delayed", []time.Duration{0}, tap, []string{"tap"}},
+		{"double tap", []time.Duration{0, 20 * ms}, tap, []string{"double-tap"}},
+		{"taps too far apart", []time.Duration{0, 250 * ms}, tap, []string{"tap", "tap"}},
+		{
+			name:    "long press",
+			touches: []time.Duration{0},
+			steps:   []touchStep{{TouchPhaseBegin, 0, 100, 100}, {TouchPhaseEnd, 200 * ms, 100, 100}},
+			want:    []string{"long-press"},
+		},
+		{
+			name:    "drag cancels long press",
+			touches: []time.Duration{0},
+			steps: []touchStep{
+				{TouchPhaseBegin, 0, 100, 100},
+				{TouchPhaseMove, 20 * ms, 150, 100},
+				{TouchPhaseEnd, 450 * ms, 150, 100},
+			},
+			want: []string{"drag-start", "drag-done"},
+		},
+	}
+	for _, tt := range tests {
+		d, r, g := newGestureRegion(t, c)
+		start := time.Now()
+		for _, at := range tt.touches {
+			playTouch(d, r, 1, start.Add(at), tt.steps, true)
+		}
+		// Wait for any delayed taps.
+		time.Sleep(2 * c.DoubleTapWindow)
+		if got := g.summary(); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ require (
 	github.com/jphsd/graphics2d v0.0.0-20231205042405-a59d2a584501
 	go.bug.st/serial v1.6.0
 	golang.org/x/image v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 	maze.io/x/pixel v0.1.5
 )
 
@@ -0,0 +1,282 @@
+package loupedeck
+
+import (
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+)
+
+// JogMode selects how a JogShuttle responds to its knob.
+type JogMode uint8
+
+const (
+	// Jog mode moves one step for each tick of the knob.
+	Jog JogMode = 0
+	// Shuttle mode uses the knob to pick a speed, and keeps
+	// moving at that speed until the knob is turned back to the
+	// center, clicked, or (on the CT's wheel) released.
+	Shuttle JogMode = 1
+)
+
+// JogEvent is sent by a JogShuttle on each tick of its clock while
+// it's moving.
+type JogEvent struct {
+	Mode JogMode
+	// Steps is the number of whole steps (such as video frames)
+	// to move since the last event.  It's negative for moving
+	// backwards.
+	Steps int
+	// Rate is the current shuttle speed, in steps per second.
+	// It's always 0 in Jog mode.
+	Rate float64
+	// Offset is the shuttle position, from -len(speeds) to
+	// len(speeds).  It's always 0 in Jog mode.
+	Offset int
+}
+
+// JogFunc is a function signature used for callbacks on JogEvents.
+type JogFunc func(JogEvent)
+
+// DefaultShuttleSpeeds are the shuttle rates, in steps per second,
+// for each position away from the center.
+var DefaultShuttleSpeeds = []float64{2, 6, 12, 25, 50, 100, 200}
+
+// DefaultJogInterval is the default period of a JogShuttle's clock.
+const DefaultJogInterval = 40 * time.Millisecond
+
+// shuttleDegrees is how far a finger must move around the CT's wheel
+// to change the shuttle speed by one position.
+const shuttleDegrees = 30
+
+// JogShuttle turns a knob (or the Loupedeck CT's wheel) into a
+// video-editing style jog/shuttle control.  In Jog mode each tick of
+// the knob is a step; in Shuttle mode the knob sets a speed, which
+// continues until it's brought back to the center.  Either way,
+// events are sent at a steady rate set by the JogShuttle's clock
+// rather than whenever the knob moves.
+//
+// On the CT's wheel, Shuttle mode can also be driven by touch: put a
+// finger on the wheel and move it around the wheel to set the speed,
+// and lift it to stop.
+type JogShuttle struct {
+	device   *Device
+	knob     Knob
+	mutex    sync.Mutex
+	mode     JogMode
+	speeds   []float64
+	interval time.Duration
+	// pending holds jog ticks not yet sent.
+	pending int
+	offset  int
+	// fraction holds the partial step carried between shuttle
+	// events.
+	fraction float64
+	// touchAngle and touchOffset are the wheel angle and shuttle
+	// offset when the current touch started.
+	touching    bool
+	touchAngle  float64
+	touchOffset int
+	running     bool
+	closed      bool
+	handlers    handlerList[struct{}, JogFunc]
+	binding     *Binding
+}
+
+// NewJogShuttle creates a JogShuttle driven by a knob, starting in
+// Jog mode.  Clicking the knob stops the shuttle.
+func NewJogShuttle(d *Device, k Knob) *JogShuttle {
+	j := &JogShuttle{
+		device:   d,
+		knob:     k,
+		speeds:   DefaultShuttleSpeeds,
+		interval: DefaultJogInterval,
+	}
+
+	bindings := []*Binding{d.BindKnob(k, j.turn)}
+	if k == CTKnob {
+		bindings = append(bindings, d.BindWheelTouch(j.touch))
+	} else {
+		bindings = append(bindings, d.BindButton(Button(k), func(Button, ButtonState) { j.Stop() }))
+	}
+	j.binding = joinBindings(bindings...)
+	return j
+}
+
+// OnJog adds a callback for JogEvents.
+func (j *JogShuttle) OnJog(f JogFunc) *Binding {
+	return j.handlers.addAny(f)
+}
+
+// Mode returns the current mode.
+func (j *JogShuttle) Mode() JogMode {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.mode
+}
+
+// SetMode switches between Jog and Shuttle mode, stopping any
+// movement in progress.
+func (j *JogShuttle) SetMode(mode JogMode) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.mode = mode
+	j.reset()
+}
+
+// SetShuttleSpeeds sets the shuttle rates, in steps per second, for
+// each position away from the center.  The number of speeds sets how
+// far the shuttle can be turned.
+func (j *JogShuttle) SetShuttleSpeeds(speeds []float64) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.speeds = append([]float64{}, speeds...)
+	j.offset = j.clampOffset(j.offset)
+}
+
+// SetInterval sets the period of the JogShuttle's clock.
+func (j *JogShuttle) SetInterval(interval time.Duration) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if interval > 0 {
+		j.interval = interval
+	}
+}
+
+// Stop returns the shuttle to the center and drops any jog steps
+// that haven't been sent yet.
+func (j *JogShuttle) Stop() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.reset()
+}
+
+// Close unbinds the JogShuttle from its knob and stops its clock.
+func (j *JogShuttle) Close() {
+	j.binding.Unbind()
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.closed = true
+	j.reset()
+}
+
+// reset stops all movement.  The caller must hold the mutex.
+func (j *JogShuttle) reset() {
+	j.pending = 0
+	j.offset = 0
+	j.fraction = 0
+	j.touching = false
+}
+
+func (j *JogShuttle) clampOffset(offset int) int {
+	n := len(j.speeds)
+	return max(-n, min(n, offset))
+}
+
+// turn handles a turn of the knob.
+func (j *JogShuttle) turn(_ Knob, delta int) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.mode == Jog {
+		j.pending += delta
+	} else if !j.touching {
+		j.offset = j.clampOffset(j.offset + delta)
+	}
+	j.start()
+}
+
+// touch handles a touch on the CT's wheel.  Only Shuttle mode uses
+// touches.
+func (j *JogShuttle) touch(e WheelTouchEvent) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if j.mode != Shuttle {
+		return
+	}
+
+	switch e.Phase {
+	case TouchPhaseBegin:
+		j.touching = true
+		j.touchAngle = e.Angle
+		j.touchOffset = j.offset
+	case TouchPhaseMove:
+		if !j.touching {
+			return
+		}
+		// Measure the shortest way around the wheel, so that
+		// crossing the top doesn't jump a full turn.
+		diff := math.Mod(e.Angle-j.touchAngle+540, 360) - 180
+		j.offset = j.clampOffset(j.touchOffset + int(math.Round(diff/shuttleDegrees)))
+	case TouchPhaseEnd:
+		if j.touching {
+			j.touching = false
+			j.offset = 0
+		}
+	}
+	j.start()
+}
+
+// start starts the clock if it isn't already running.  The caller
+// must hold the mutex.
+func (j *JogShuttle) start() {
+	if j.running || j.closed {
+		return
+	}
+	j.running = true
+	go j.clock()
+}
+
+// rate returns the shuttle speed for the current offset.  The
+// caller must hold the mutex.
+func (j *JogShuttle) rate() float64 {
+	switch {
+	case j.offset > 0:
+		return j.speeds[j.offset-1]
+	case j.offset < 0:
+		return -j.speeds[-j.offset-1]
+	}
+	return 0
+}
+
+// clock sends JogEvents at a steady rate until the JogShuttle comes
+// to rest.
+func (j *JogShuttle) clock() {
+	j.mutex.Lock()
+	interval := j.interval
+	j.mutex.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := time.Now()
+
+	for now := range ticker.C {
+		j.mutex.Lock()
+		dt := now.Sub(last).Seconds()
+		last = now
+
+		e := JogEvent{Mode: j.mode, Offset: j.offset}
+		if j.mode == Jog {
+			e.Steps = j.pending
+			j.pending = 0
+		} else {
+			e.Rate = j.rate()
+			j.fraction += e.Rate * dt
+			e.Steps = int(j.fraction)
+			j.fraction -= float64(e.Steps)
+		}
+
+		if j.closed || (e.Steps == 0 && e.Rate == 0) {
+			j.running = false
+			j.fraction = 0
+			j.mutex.Unlock()
+			return
+		}
+		j.mutex.Unlock()
+
+		slog.Info("Jog", "knob", j.knob, "mode", e.Mode, "steps", e.Steps, "rate", e.Rate)
+		for _, f := range j.handlers.get(struct{}{}) {
+			f := f
+			j.device.run(controlKey{knobControl, uint32(j.knob)}, func() { f(e) })
+		}
+	}
+}
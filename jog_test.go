@@ -0,0 +1,107 @@
+package loupedeck
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShuttleOffset(t *testing.T) {
+	speeds := []float64{10, 20, 30}
+	tests := []struct {
+		name     string
+		turns    []int
+		touches  []WheelTouchEvent
+		want     int
+		wantRate float64
+	}{
+		{"center", nil, nil, 0, 0},
+		{"forward", []int{1, 1}, nil, 2, 20},
+		{"backward", []int{-1}, nil, -1, -10},
+		{"clamped", []int{5}, nil, 3, 30},
+		{"clamped backward", []int{-2, -2}, nil, -3, -30},
+		{"touch", nil, []WheelTouchEvent{
+			{Phase: TouchPhaseBegin, Angle: 10},
+			{Phase: TouchPhaseMove, Angle: 70},
+		}, 2, 20},
+		{"touch across the top", nil, []WheelTouchEvent{
+			{Phase: TouchPhaseBegin, Angle: 10},
+			{Phase: TouchPhaseMove, Angle: 340},
+		}, -1, -10},
+		{"touch released", nil, []WheelTouchEvent{
+			{Phase: TouchPhaseBegin, Angle: 10},
+			{Phase: TouchPhaseMove, Angle: 70},
+			{Phase: TouchPhaseEnd, Angle: 70},
+		}, 0, 0},
+		{"turns ignored while touching", []int{1}, []WheelTouchEvent{
+			{Phase: TouchPhaseBegin, Angle: 10},
+		}, 0, 0},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0003"})
+		j := NewJogShuttle(d, CTKnob)
+		j.SetMode(Shuttle)
+		j.SetShuttleSpeeds(speeds)
+		for _, e := range tt.touches {
+			j.touch(e)
+		}
+		for _, delta := range tt.turns {
+			j.turn(CTKnob, delta)
+		}
+
+		j.mutex.Lock()
+		if j.offset != tt.want || j.rate() != tt.wantRate {
+			t.Errorf("%s: offset %d at rate %v, want %d at %v", tt.name, j.offset, j.rate(), tt.want, tt.wantRate)
+		}
+		j.mutex.Unlock()
+		j.Close()
+	}
+}
+
+func TestJogEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  JogMode
+		turns []int
+		// want is the total number of steps sent.
+		want int
+	}{
+		{"jog", Jog, []int{1, 1, 1}, 3},
+		{"jog backward", Jog, []int{-2, 1}, -1},
+		{"shuttle", Shuttle, []int{1}, 5},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		j := NewJogShuttle(d, Knob1)
+		j.SetMode(tt.mode)
+		j.SetShuttleSpeeds([]float64{50})
+		j.SetInterval(10 * time.Millisecond)
+		var mutex sync.Mutex
+		var got int
+		j.OnJog(func(e JogEvent) {
+			mutex.Lock()
+			got += e.Steps
+			mutex.Unlock()
+		})
+
+		for _, delta := range tt.turns {
+			j.turn(Knob1, delta)
+		}
+		if tt.mode == Shuttle {
+			// Run at 50 steps per second for about 100ms.
+			time.Sleep(105 * time.Millisecond)
+			j.Stop()
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		mutex.Lock()
+		if tt.mode == Jog && got != tt.want {
+			t.Errorf("%s: sent %d steps, want %d", tt.name, got, tt.want)
+		}
+		if tt.mode == Shuttle && (got < tt.want-1 || got > tt.want+1) {
+			t.Errorf("%s: sent %d steps, want about %d", tt.name, got, tt.want)
+		}
+		mutex.Unlock()
+		j.Close()
+	}
+}
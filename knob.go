@@ -1,10 +1,16 @@
 package loupedeck
 
+import (
+	"math"
+	"time"
+)
+
 // Knob represents the 6 knobs on the Loupedeck Live.
 type Knob uint16
 
 const (
-	// CTKnob is the large knob in the center of the Loupedeck CT
+	// CTKnob is the large wheel in the center of the Loupedeck CT.
+	// See also BindWheel.
 	CTKnob Knob = 0
 	// Knob1 is the upper left knob.
 	Knob1 Knob = 1
@@ -22,14 +28,122 @@ const (
 
 // KnobFunc is a function signature used for callbacks on Knob events,
 // similar to ButtonFunc's use with Button events.  The exact use of
-// the second parameter depends on the use; in some cases it's simply
-// +1/-1 (for right/left button turns) and in other cases it's the
-// current value of the dial.
+// the second parameter depends on the use; in most cases it's the
+// number of ticks turned (positive for clockwise, negative for
+// counter-clockwise, and possibly more than 1 for fast turns or
+// with acceleration) and in other cases it's the current value of
+// the dial.
 type KnobFunc func(Knob, int)
 
-// BindKnob sets a callback for actions on a specific
+// BindKnob adds a callback for actions on a specific
 // knob.  When the Knob is turned then the provided
-// KnobFunc is called.
-func (d *Device) BindKnob(k Knob, f KnobFunc) {
-	d.knobBindings[k] = f
+// KnobFunc is called.  Any number of callbacks may be bound to the
+// same Knob.
+func (d *Device) BindKnob(k Knob, f KnobFunc) *Binding {
+	return d.bindings.knob.add(k, f)
+}
+
+// BindAnyKnob adds a callback that is called when any Knob is
+// turned, after the callbacks for that specific Knob.
+func (d *Device) BindAnyKnob(f KnobFunc) *Binding {
+	return d.bindings.knob.addAny(f)
+}
+
+// AccelerationStep is a single step in an AccelerationCurve.
+type AccelerationStep struct {
+	// MinSpeed is the turning speed, in ticks per second, at
+	// which this step starts to apply.
+	MinSpeed float64
+	// Multiplier is applied to each tick while the knob is
+	// turning at least MinSpeed.
+	Multiplier int
+}
+
+// AccelerationCurve makes fast knob turns cover more ground than
+// slow ones, so that a knob can make both single-step adjustments
+// and coarse sweeps across a large range.  The steps should be
+// sorted by MinSpeed; the last step that the knob's speed reaches
+// applies, and turns slower than the first step are not multiplied.
+type AccelerationCurve []AccelerationStep
+
+// DefaultAcceleration is a reasonable AccelerationCurve for
+// adjusting values with a range of a few thousand.
+var DefaultAcceleration = AccelerationCurve{
+	{MinSpeed: 10, Multiplier: 2},
+	{MinSpeed: 20, Multiplier: 5},
+	{MinSpeed: 40, Multiplier: 20},
+	{MinSpeed: 80, Multiplier: 100},
+}
+
+// knobIdle is how long a knob must be still before its speed is
+// reset to zero.
+const knobIdle = 250 * time.Millisecond
+
+// knobState tracks how fast a knob is turning, and how far it has
+// turned in total.
+type knobState struct {
+	curve    AccelerationCurve
+	last     time.Time
+	speed    float64
+	position int
+
+	// Coalescing state; see SetKnobCoalescing.
+	coalesce bool
+	window   time.Duration
+	pending  int
+	busy     bool
+	timer    *time.Timer
+}
+
+// SetKnobAcceleration sets the AccelerationCurve for a knob.  The
+// deltas passed to KnobFuncs are multiplied according to the curve.
+// Passing nil turns acceleration off again.
+func (d *Device) SetKnobAcceleration(k Knob, c AccelerationCurve) {
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+	d.knobState(k).curve = c
+}
+
+// knobState returns the state for a knob, creating it if needed.
+// The caller must hold knobMutex.
+func (d *Device) knobState(k Knob) *knobState {
+	s := d.knobs[k]
+	if s == nil {
+		s = &knobState{}
+		d.knobs[k] = s
+	}
+	return s
+}
+
+// decodeKnobDelta turns the rotation byte from a KnobRotate message
+// into a signed number of ticks.
+func decodeKnobDelta(b byte) int {
+	return int(int8(b))
+}
+
+// accelerate applies the knob's AccelerationCurve to a delta.
+func (d *Device) accelerate(k Knob, delta int, now time.Time) int {
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+
+	s := d.knobState(k)
+	dt := now.Sub(s.last)
+	s.last = now
+	ticks := math.Abs(float64(delta))
+	switch {
+	case dt > knobIdle || dt <= 0:
+		s.speed = 0
+	default:
+		// Smooth the speed, as individual ticks arrive at
+		// fairly irregular intervals.
+		s.speed = 0.5*s.speed + 0.5*ticks/dt.Seconds()
+	}
+
+	multiplier := 1
+	for _, step := range s.curve {
+		if s.speed >= step.MinSpeed {
+			multiplier = step.Multiplier
+		}
+	}
+	return delta * multiplier
 }
@@ -0,0 +1,62 @@
+package loupedeck
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecodeKnobDelta(t *testing.T) {
+	tests := []struct {
+		b    byte
+		want int
+	}{
+		{0x00, 0},
+		{0x01, 1},
+		{0x05, 5},
+		{0x7f, 127},
+		{0xff, -1},
+		{0xfb, -5},
+		{0x80, -128},
+	}
+	for _, tt := range tests {
+		if got := decodeKnobDelta(tt.b); got != tt.want {
+			t.Errorf("decodeKnobDelta(%#x) = %d, want %d", tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAccelerate(t *testing.T) {
+	curve := AccelerationCurve{
+		{MinSpeed: 10, Multiplier: 2},
+		{MinSpeed: 50, Multiplier: 10},
+	}
+	tests := []struct {
+		name  string
+		curve AccelerationCurve
+		// gaps are the times between ticks, each of which is
+		// turned by delta.
+		gaps  []time.Duration
+		delta int
+		want  int
+	}{
+		{"no curve", nil, []time.Duration{time.Millisecond, time.Millisecond}, 1, 1},
+		{"first tick", curve, nil, 1, 1},
+		{"slow", curve, []time.Duration{200 * time.Millisecond}, 1, 1},
+		{"medium", curve, []time.Duration{50 * time.Millisecond, 50 * time.Millisecond}, 1, 2},
+		{"fast", curve, []time.Duration{5 * time.Millisecond, 5 * time.Millisecond}, -1, -10},
+		{"after a pause", curve, []time.Duration{5 * time.Millisecond, time.Second}, 1, 1},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		d.SetKnobAcceleration(Knob1, tt.curve)
+		now := time.Now()
+		got := d.accelerate(Knob1, tt.delta, now)
+		for _, gap := range tt.gaps {
+			now = now.Add(gap)
+			got = d.accelerate(Knob1, tt.delta, now)
+		}
+		if got != tt.want {
+			t.Errorf("%s: last tick accelerated to %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
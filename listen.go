@@ -2,7 +2,9 @@ package loupedeck
 
 import (
 	"encoding/binary"
+	"image"
 	"log/slog"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -11,10 +13,12 @@ import (
 // callbacks as configured.
 func (d *Device) Listen() error {
 	slog.Info("Listening ...")
+	d.publish(ConnectionEvent{EventInfo: d.eventInfo(), Connected: true})
 	for {
 		websocketMsgType, data, err := d.conn.ReadMessage()
 		if err != nil {
 			slog.Warn("Read error, exiting", "error", err)
+			d.closeEvents(err)
 			return err
 		}
 
@@ -31,10 +35,13 @@ func (d *Device) Listen() error {
 		msg, _ := d.ParseMessage(data)
 
 		if msg.transactionID != 0 {
-			if cb := d.transactionCallbacks[msg.transactionID]; cb != nil {
+			d.transactionMutex.Lock()
+			cb := d.transactionCallbacks[msg.transactionID]
+			delete(d.transactionCallbacks, msg.transactionID)
+			d.transactionMutex.Unlock()
+			if cb != nil {
 				slog.Info("Callback found with", "txid", msg.transactionID)
-				cb(msg)
-				d.transactionCallbacks[msg.transactionID] = nil
+				d.safely(func() { cb(msg) })
 			}
 			continue
 		}
@@ -42,54 +49,92 @@ func (d *Device) Listen() error {
 		switch msg.messageType {
 
 		case ButtonPress:
-			button := Button(binary.BigEndian.Uint16(data[2:]))
+			button := d.logicalButton(Button(binary.BigEndian.Uint16(data[2:])))
 			upDown := ButtonState(data[4])
 
 			slog.Info("Received button press message", "button", button, "upDown", upDown, "message", data)
+			d.trackButton(button, upDown)
+			d.publish(ButtonEvent{EventInfo: d.eventInfo(), Button: button, State: upDown})
 
-			if upDown == ButtonDown && d.buttonBindings[button] != nil {
-				d.buttonBindings[button](button, upDown)
-			} else if upDown == ButtonUp && d.buttonUpBindings[button] != nil {
-				d.buttonUpBindings[button](button, upDown)
-			}
+			d.handleButton(button, upDown)
 
 		case KnobRotate:
-			knob := Knob(binary.BigEndian.Uint16(data[2:]))
-			value := int(data[4])
+			knob := d.logicalKnob(Knob(binary.BigEndian.Uint16(data[2:])))
+			raw := decodeKnobDelta(data[4])
 
-			slog.Info("Received knob rotate message", "knob", knob, "value", value, "message", data)
+			slog.Info("Received knob rotate message", "knob", knob, "value", raw, "message", data)
 
-			if d.knobBindings[knob] != nil {
-				v := value
-				if value == 255 {
-					v = -1
-				}
-				d.knobBindings[knob](knob, v)
-			}
+			d.trackKnob(knob, raw)
+			v := d.accelerate(knob, raw, time.Now())
+			d.publish(KnobEvent{EventInfo: d.eventInfo(), Knob: knob, Delta: v, RawDelta: raw})
+
+			d.coalesceKnob(knob, v)
 
 		case Touch:
 			x := binary.BigEndian.Uint16(data[4:])
 			y := binary.BigEndian.Uint16(data[6:])
-			id := data[8] // Not sure what this is for
-			b := CoordToTouchButton(x, y)
+			id := data[8] // Per-finger ID, used to track multi-touch sessions
+			x, y = d.touchToLogical(x, y)
+			b := d.CoordToTouchButton(x, y)
 
 			slog.Info("Received touch message", "x", x, "y", y, "id", id, "b", b, "message", data)
 
-			if d.touchBindings[b] != nil {
-				d.touchBindings[b](b, ButtonDown, x, y)
+			// The device repeats touch messages while a finger
+			// is down; only the first one is a new touch.
+			s, phase := d.trackTouch(id, b, image.Pt(int(x), int(y)), time.Now())
+			if phase == TouchPhaseBegin {
+				d.handleTouch(id, b, ButtonDown, x, y)
 			}
+			d.publish(TouchEvent{EventInfo: d.eventInfo(), Button: b, Phase: phase, X: x, Y: y, Session: *s})
+			d.dispatchTouchRegion(phase, s, false)
+			d.dispatchTouchSession(phase, s)
 
 		case TouchEnd:
 			x := binary.BigEndian.Uint16(data[4:])
 			y := binary.BigEndian.Uint16(data[6:])
-			id := data[8] // Not sure what this is for
-			b := CoordToTouchButton(x, y)
+			id := data[8] // Per-finger ID, used to track multi-touch sessions
+			x, y = d.touchToLogical(x, y)
+			b := d.CoordToTouchButton(x, y)
 
 			slog.Info("Received touch end message", "x", x, "y", y, "id", id, "b", b, "message", data)
 
-			if d.touchUpBindings[b] != nil {
-				d.touchUpBindings[b](b, ButtonUp, x, y)
+			s := d.endTouch(id, b, image.Pt(int(x), int(y)), time.Now())
+			d.handleTouch(id, b, ButtonUp, x, y)
+			d.publish(TouchEvent{EventInfo: d.eventInfo(), Button: b, Phase: TouchPhaseEnd, X: x, Y: y, Session: *s})
+			d.dispatchTouchRegion(TouchPhaseEnd, s, false)
+			d.dispatchTouchSession(TouchPhaseEnd, s)
+
+		case TouchCT:
+			x := binary.BigEndian.Uint16(data[4:])
+			y := binary.BigEndian.Uint16(data[6:])
+			id := data[8] // Per-finger ID, used to track multi-touch sessions
+			x, y = d.wheelToLogical(x, y)
+
+			slog.Info("Received wheel touch message", "x", x, "y", y, "id", id, "message", data)
+
+			s, phase := d.trackTouch(id, TouchWheel, image.Pt(int(x), int(y)), time.Now())
+			if phase == TouchPhaseBegin {
+				d.handleTouch(id, TouchWheel, ButtonDown, x, y)
 			}
+			d.publish(TouchEvent{EventInfo: d.eventInfo(), Button: TouchWheel, Phase: phase, X: x, Y: y, Session: *s})
+			d.dispatchTouchRegion(phase, s, true)
+			d.dispatchTouchSession(phase, s)
+			d.dispatchWheelTouch(phase, s)
+
+		case TouchEndCT:
+			x := binary.BigEndian.Uint16(data[4:])
+			y := binary.BigEndian.Uint16(data[6:])
+			id := data[8] // Per-finger ID, used to track multi-touch sessions
+			x, y = d.wheelToLogical(x, y)
+
+			slog.Info("Received wheel touch end message", "x", x, "y", y, "id", id, "message", data)
+
+			s := d.endTouch(id, TouchWheel, image.Pt(int(x), int(y)), time.Now())
+			d.handleTouch(id, TouchWheel, ButtonUp, x, y)
+			d.publish(TouchEvent{EventInfo: d.eventInfo(), Button: TouchWheel, Phase: TouchPhaseEnd, X: x, Y: y, Session: *s})
+			d.dispatchTouchRegion(TouchPhaseEnd, s, true)
+			d.dispatchTouchSession(TouchPhaseEnd, s)
+			d.dispatchWheelTouch(TouchPhaseEnd, s)
 
 		case 0x73:
 			// seems to be some websocket information, we ignore it
@@ -116,7 +116,9 @@ func (d *Device) newTransactionID() uint8 {
 // Send sends a message to the specified device.
 func (d *Device) Send(m *Message) error {
 	// slog.Info("Sending", "message", m.String())
-	d.transactionCallbacks[m.transactionID] = nil
+	d.transactionMutex.Lock()
+	delete(d.transactionCallbacks, m.transactionID)
+	d.transactionMutex.Unlock()
 
 	return d.send(m)
 }
@@ -127,7 +129,9 @@ func (d *Device) Send(m *Message) error {
 // provided with the response message.
 func (d *Device) SendWithCallback(m *Message, c transactionCallback) error {
 	slog.Info("Setting callback", "message", m.String())
+	d.transactionMutex.Lock()
 	d.transactionCallbacks[m.transactionID] = c
+	d.transactionMutex.Unlock()
 
 	return d.send(m)
 }
@@ -169,8 +173,12 @@ func (d *Device) SendAndWait(m *Message, timeout time.Duration) (*Message, error
 	}
 }
 
-// send sends a message to the specified device.
+// send sends a message to the specified device.  Websocket
+// connections don't support concurrent writers, so sends are
+// serialized.
 func (d *Device) send(m *Message) error {
 	b := m.asBytes()
+	d.writeMutex.Lock()
+	defer d.writeMutex.Unlock()
 	return d.conn.WriteMessage(websocket.BinaryMessage, b)
 }
@@ -0,0 +1,172 @@
+package loupedeck
+
+import (
+	"image"
+	"slices"
+)
+
+// TouchLayout describes where a model's touch keys and side strips
+// sit on its touchscreen.  All coordinates are in touchscreen pixels
+// with the device upright; see Device.TouchKeyRect for coordinates
+// in the device's current orientation.
+type TouchLayout struct {
+	// Columns and Rows give the size of the key grid.
+	Columns, Rows int
+	// KeyWidth and KeyHeight give the size of a single key.
+	KeyWidth, KeyHeight int
+	// GapX and GapY give the space between adjacent keys.
+	GapX, GapY int
+	// Origin is the top-left corner of Touch1.
+	Origin image.Point
+	// LeftStrip and RightStrip are the touch areas beside the
+	// knobs.  They're empty on models without side strips.
+	LeftStrip, RightStrip image.Rectangle
+	// Wheel is the round touch surface in the middle of the CT's
+	// wheel, in "dial" display coordinates.  It's empty on models
+	// without a wheel.
+	Wheel image.Rectangle
+}
+
+// Keys returns the rectangle of each touch key, in order from Touch1.
+func (t TouchLayout) Keys() []image.Rectangle {
+	keys := make([]image.Rectangle, 0, t.Columns*t.Rows)
+	for row := 0; row < t.Rows; row++ {
+		for col := 0; col < t.Columns; col++ {
+			min := t.Origin.Add(image.Pt(col*(t.KeyWidth+t.GapX), row*(t.KeyHeight+t.GapY)))
+			keys = append(keys, image.Rectangle{min, min.Add(image.Pt(t.KeyWidth, t.KeyHeight))})
+		}
+	}
+	return keys
+}
+
+// displaySpec describes one display on a model.
+type displaySpec struct {
+	name             string
+	id               byte
+	width, height    int
+	offsetx, offsety int
+	bigEndian        bool
+}
+
+// model describes the hardware layout of a specific Loupedeck model.
+type model struct {
+	name     string
+	displays []displaySpec
+	touch    TouchLayout
+	// leftKnobs and rightKnobs are the knobs beside the left and
+	// right edges of the touchscreen, top to bottom.
+	leftKnobs, rightKnobs []Knob
+	// buttons are the buttons below the touchscreen, left to right.
+	buttons []Button
+}
+
+// hasKnob returns true if the model has the knob.
+func (m *model) hasKnob(k Knob) bool {
+	if k == CTKnob {
+		return !m.touch.Wheel.Empty()
+	}
+	return slices.Contains(m.leftKnobs, k) || slices.Contains(m.rightKnobs, k)
+}
+
+// hasButton returns true if the model has the button.  The CT's
+// extra buttons are assumed to go along with its wheel.
+func (m *model) hasButton(b Button) bool {
+	switch {
+	case b >= KnobButton1 && b <= KnobButton6:
+		return m.hasKnob(Knob(b))
+	case b >= CTCircle:
+		return !m.touch.Wheel.Empty()
+	}
+	return slices.Contains(m.buttons, b)
+}
+
+// liveTouchLayout is the 60px side strips plus a 4x3 grid of 90px
+// keys used by the Loupedeck Live and its relatives.
+var liveTouchLayout = TouchLayout{
+	Columns:    4,
+	Rows:       3,
+	KeyWidth:   90,
+	KeyHeight:  90,
+	Origin:     image.Pt(60, 0),
+	LeftStrip:  image.Rect(0, 0, 60, 270),
+	RightStrip: image.Rect(420, 0, 480, 270),
+}
+
+var liveDisplays = []displaySpec{
+	{name: "left", id: 'M', width: 60, height: 270},
+	{name: "main", id: 'M', width: 360, height: 270, offsetx: 60},
+	{name: "right", id: 'M', width: 60, height: 270, offsetx: 420},
+	{name: "all", id: 'M', width: 480, height: 270},
+}
+
+var liveModel = model{
+	name:       "Loupedeck Live",
+	displays:   liveDisplays,
+	touch:      liveTouchLayout,
+	leftKnobs:  []Knob{Knob1, Knob2, Knob3},
+	rightKnobs: []Knob{Knob4, Knob5, Knob6},
+	buttons:    []Button{Button0, Button1, Button2, Button3, Button4, Button5, Button6, Button7},
+}
+
+// models maps USB product IDs onto hardware layouts.
+var models = map[string]model{
+	"0003": {
+		name: "Loupedeck CT v1",
+		displays: []displaySpec{
+			{name: "left", id: 'L', width: 60, height: 270},
+			{name: "main", id: 'A', width: 360, height: 270, offsetx: 60},
+			{name: "right", id: 'R', width: 60, height: 270, offsetx: 420},
+			{name: "dial", id: 'W', width: 240, height: 240, bigEndian: true},
+		},
+		touch:      withWheel(liveTouchLayout),
+		leftKnobs:  liveModel.leftKnobs,
+		rightKnobs: liveModel.rightKnobs,
+		buttons:    liveModel.buttons,
+	},
+	"0007": {
+		name: "Loupedeck CT v2",
+		displays: append(append([]displaySpec{}, liveDisplays...),
+			displaySpec{name: "dial", id: 'W', width: 240, height: 240, bigEndian: true}),
+		touch:      withWheel(liveTouchLayout),
+		leftKnobs:  liveModel.leftKnobs,
+		rightKnobs: liveModel.rightKnobs,
+		buttons:    liveModel.buttons,
+	},
+	"0004": liveModel,
+	"0006": {
+		// The Live S has no side strips; its 5x3 grid of keys is
+		// centered on the same 480x270 screen as the Live's, and
+		// spans the "left", "main", and "right" displays.  Use
+		// "all" to draw across the whole screen.
+		name:     "Loupedeck Live S",
+		displays: liveDisplays,
+		touch: TouchLayout{
+			Columns:   5,
+			Rows:      3,
+			KeyWidth:  90,
+			KeyHeight: 90,
+			Origin:    image.Pt(15, 0),
+		},
+		leftKnobs: []Knob{Knob1, Knob2},
+		buttons:   []Button{Button0, Button1, Button2, Button3},
+	},
+	"0d06": {
+		name:       "Razer Stream Controller",
+		displays:   liveModel.displays,
+		touch:      liveModel.touch,
+		leftKnobs:  liveModel.leftKnobs,
+		rightKnobs: liveModel.rightKnobs,
+		buttons:    liveModel.buttons,
+	},
+}
+
+func withWheel(t TouchLayout) TouchLayout {
+	t.Wheel = image.Rect(0, 0, 240, 240)
+	return t
+}
+
+// TouchLayout returns the touch key geometry of the connected model,
+// with the device upright.
+func (d *Device) TouchLayout() TouchLayout {
+	return d.model.touch
+}
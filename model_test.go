@@ -0,0 +1,79 @@
+package loupedeck
+
+import (
+	"image"
+	"testing"
+)
+
+func TestModelDisplays(t *testing.T) {
+	tests := []struct {
+		product string
+		name    string
+		rect    image.Rectangle
+	}{
+		{"0004", "left", image.Rect(0, 0, 60, 270)},
+		{"0004", "main", image.Rect(60, 0, 420, 270)},
+		{"0004", "right", image.Rect(420, 0, 480, 270)},
+		{"0004", "all", image.Rect(0, 0, 480, 270)},
+		{"0006", "left", image.Rect(0, 0, 60, 270)},
+		{"0006", "main", image.Rect(60, 0, 420, 270)},
+		{"0006", "right", image.Rect(420, 0, 480, 270)},
+		{"0006", "all", image.Rect(0, 0, 480, 270)},
+		{"0007", "main", image.Rect(60, 0, 420, 270)},
+		{"0007", "dial", image.Rect(0, 0, 240, 240)},
+		{"0003", "left", image.Rect(0, 0, 60, 270)},
+		{"0003", "dial", image.Rect(0, 0, 240, 240)},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: tt.product})
+		disp := d.GetDisplay(tt.name)
+		if disp == nil {
+			t.Errorf("%s: no %q display", tt.product, tt.name)
+			continue
+		}
+		if r := disp.logicalRect(); r != tt.rect {
+			t.Errorf("%s: %q display covers %v, want %v", tt.product, tt.name, r, tt.rect)
+		}
+	}
+}
+
+func TestCoordToTouchButton(t *testing.T) {
+	tests := []struct {
+		product string
+		x, y    uint16
+		want    TouchButton
+	}{
+		{"0004", 10, 100, TouchLeft},
+		{"0004", 470, 100, TouchRight},
+		{"0004", 60, 0, Touch1},
+		{"0004", 419, 0, Touch4},
+		{"0004", 150, 100, Touch6},
+		{"0004", 419, 269, Touch12},
+		{"0006", 10, 100, TouchNone},
+		{"0006", 15, 0, Touch1},
+		{"0006", 464, 0, Touch5},
+		{"0006", 470, 0, TouchNone},
+		{"0006", 464, 269, Touch15},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: tt.product})
+		if got := d.CoordToTouchButton(tt.x, tt.y); got != tt.want {
+			t.Errorf("%s: CoordToTouchButton(%d, %d) = %v, want %v", tt.product, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestToCoord(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	main := d.GetDisplay("main").logicalRect().Min
+	for _, b := range d.TouchButtons() {
+		x, y := b.ToCoord()
+		if want := d.TouchKeyRect(b).Min.Sub(main); image.Pt(x, y) != want {
+			t.Errorf("%v.ToCoord() = %d, %d, want %v", b, x, y, want)
+		}
+	}
+	b := Touch13
+	if x, y := b.ToCoord(); x != -1 || y != -1 {
+		t.Errorf("Touch13.ToCoord() = %d, %d, want -1, -1", x, y)
+	}
+}
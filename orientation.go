@@ -0,0 +1,282 @@
+package loupedeck
+
+import (
+	"image"
+	"sort"
+)
+
+// Orientation describes how a Loupedeck is mounted relative to its
+// normal upright position.  The low two bits hold a clockwise
+// rotation in 90 degree steps, and Mirrored may be or'ed in to flip
+// the output horizontally after rotation, e.g. Rotate180|Mirrored.
+//
+// Once an orientation is set with SetOrientation, everything drawn
+// through Display.Draw is transformed to match, touch coordinates
+// are transformed back before being mapped to TouchButtons, and
+// knobs and buttons are renumbered by their logical position, so
+// that application code can be written as if the device were
+// upright.
+type Orientation uint8
+
+const (
+	// Rotate0 is the normal, upright orientation.
+	Rotate0 Orientation = 0
+	// Rotate90 is for devices turned 90 degrees clockwise.
+	Rotate90 Orientation = 1
+	// Rotate180 is for devices mounted upside-down.
+	Rotate180 Orientation = 2
+	// Rotate270 is for devices turned 90 degrees counter-clockwise.
+	Rotate270 Orientation = 3
+	// Mirrored flips the display horizontally, for example when
+	// the device is viewed through a mirror or a teleprompter.
+	Mirrored Orientation = 4
+)
+
+// Rotation returns the clockwise rotation of the orientation, in degrees.
+func (o Orientation) Rotation() int {
+	return int(o&3) * 90
+}
+
+// IsMirrored returns true if the orientation includes a horizontal flip.
+func (o Orientation) IsMirrored() bool {
+	return o&Mirrored != 0
+}
+
+// Size returns the logical size of a w x h physical area.
+func (o Orientation) Size(w, h int) (int, int) {
+	if o&1 == 1 {
+		return h, w
+	}
+	return w, h
+}
+
+// ToLogical maps the physical pixel x,y in a w x h area onto the
+// coordinates that the user sees.
+func (o Orientation) ToLogical(x, y, w, h int) (int, int) {
+	switch o & 3 {
+	case Rotate90:
+		x, y = h-1-y, x
+	case Rotate180:
+		x, y = w-1-x, h-1-y
+	case Rotate270:
+		x, y = y, w-1-x
+	}
+	if o.IsMirrored() {
+		lw, _ := o.Size(w, h)
+		x = lw - 1 - x
+	}
+	return x, y
+}
+
+// ToPhysical is the inverse of ToLogical; it maps the logical pixel
+// x,y back onto a w x h physical area.
+func (o Orientation) ToPhysical(x, y, w, h int) (int, int) {
+	if o.IsMirrored() {
+		lw, _ := o.Size(w, h)
+		x = lw - 1 - x
+	}
+	switch o & 3 {
+	case Rotate90:
+		x, y = y, h-1-x
+	case Rotate180:
+		x, y = w-1-x, h-1-y
+	case Rotate270:
+		x, y = w-1-y, x
+	}
+	return x, y
+}
+
+// RectToPhysical maps a logical rectangle onto a w x h physical area.
+func (o Orientation) RectToPhysical(r image.Rectangle, w, h int) image.Rectangle {
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+	x0, y0 := o.ToPhysical(r.Min.X, r.Min.Y, w, h)
+	x1, y1 := o.ToPhysical(r.Max.X-1, r.Max.Y-1, w, h)
+	return image.Rect(x0, y0, x0+1, y0+1).Union(image.Rect(x1, y1, x1+1, y1+1))
+}
+
+// RectToLogical maps a physical rectangle in a w x h area onto
+// logical coordinates.
+func (o Orientation) RectToLogical(r image.Rectangle, w, h int) image.Rectangle {
+	if r.Empty() {
+		return image.Rectangle{}
+	}
+	x0, y0 := o.ToLogical(r.Min.X, r.Min.Y, w, h)
+	x1, y1 := o.ToLogical(r.Max.X-1, r.Max.Y-1, w, h)
+	return image.Rect(x0, y0, x0+1, y0+1).Union(image.Rect(x1, y1, x1+1, y1+1))
+}
+
+// transformImage renders im, which covers the logical rectangle r of
+// a w x h area, into the matching physical rectangle.
+func (o Orientation) transformImage(im image.Image, r image.Rectangle, w, h int) (image.Image, image.Rectangle) {
+	pr := o.RectToPhysical(r, w, h)
+	if o == Rotate0 {
+		return im, pr
+	}
+
+	out := image.NewRGBA(pr)
+	b := im.Bounds()
+	for py := pr.Min.Y; py < pr.Max.Y; py++ {
+		for px := pr.Min.X; px < pr.Max.X; px++ {
+			lx, ly := o.ToLogical(px, py, w, h)
+			out.Set(px, py, im.At(b.Min.X+lx-r.Min.X, b.Min.Y+ly-r.Min.Y))
+		}
+	}
+	return out, pr
+}
+
+// SetOrientation sets the mounting orientation of the device.  It
+// does not redraw anything; callers should redraw after changing the
+// orientation.
+func (d *Device) SetOrientation(o Orientation) {
+	d.orientation = o
+	d.updateLayout()
+}
+
+// Orientation returns the current mounting orientation of the device.
+func (d *Device) Orientation() Orientation {
+	return d.orientation
+}
+
+// surfaceSize returns the size of the main touchscreen surface,
+// covering the left, main, and right displays.
+func (d *Device) surfaceSize() (int, int) {
+	w, h := 0, 0
+	for name, disp := range d.displays {
+		if name == "dial" {
+			continue
+		}
+		w = max(w, disp.offsetx+disp.width)
+		h = max(h, disp.offsety+disp.height)
+	}
+	return w, h
+}
+
+// touchToLogical maps a touch reported by the hardware onto the
+// logical touchscreen coordinates seen by the user.
+func (d *Device) touchToLogical(x, y uint16) (uint16, uint16) {
+	if d.orientation == Rotate0 {
+		return x, y
+	}
+	w, h := d.surfaceSize()
+	lx, ly := d.orientation.ToLogical(int(x), int(y), w, h)
+	return uint16(lx), uint16(ly)
+}
+
+// readsBefore returns true if a comes before b when reading
+// left-to-right, top-to-bottom.
+func readsBefore(a, b image.Point) bool {
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
+
+// sidesSwapped returns true if the physical right side of the
+// device is on the user's left in the current orientation.  When it
+// is, the "left" and "right" displays and their knobs trade places.
+func (d *Device) sidesSwapped() bool {
+	if d.orientation == Rotate0 {
+		return false
+	}
+	w, h := d.surfaceSize()
+	lx, ly := d.orientation.ToLogical(0, h/2, w, h)
+	rx, ry := d.orientation.ToLogical(w-1, h/2, w, h)
+	return readsBefore(image.Pt(rx, ry), image.Pt(lx, ly))
+}
+
+// updateLayout renumbers knobs and buttons so that their logical
+// numbers follow their position in the current orientation, and
+// recomputes where each TouchButton sits.  Knob1-3 are always the
+// knobs next to the "left" display, top to bottom, and buttons are
+// numbered left to right (or top to bottom, for 90 and 270 degree
+// rotations).
+func (d *Device) updateLayout() {
+	d.knobToLogical = map[Knob]Knob{}
+	d.knobToPhysical = map[Knob]Knob{}
+	d.buttonToLogical = map[Button]Button{}
+	d.buttonToPhysical = map[Button]Button{}
+	d.updateTouchKeys()
+
+	w, h := d.surfaceSize()
+	if d.orientation == Rotate0 || w == 0 || h == 0 {
+		return
+	}
+
+	logical := func(x, y int) image.Point {
+		lx, ly := d.orientation.ToLogical(x, y, w, h)
+		return image.Pt(lx, ly)
+	}
+
+	knobPos := map[Knob]image.Point{}
+	for i, k := range d.model.leftKnobs {
+		knobPos[k] = logical(0, (2*i+1)*h/(2*len(d.model.leftKnobs)))
+	}
+	for i, k := range d.model.rightKnobs {
+		knobPos[k] = logical(w-1, (2*i+1)*h/(2*len(d.model.rightKnobs)))
+	}
+
+	left := append([]Knob{}, d.model.leftKnobs...)
+	right := append([]Knob{}, d.model.rightKnobs...)
+	if d.sidesSwapped() {
+		left, right = right, left
+	}
+
+	logicalKnobs := append(append([]Knob{}, d.model.leftKnobs...), d.model.rightKnobs...)
+	i := 0
+	for _, group := range [][]Knob{left, right} {
+		sort.Slice(group, func(a, b int) bool {
+			return readsBefore(knobPos[group[a]], knobPos[group[b]])
+		})
+		for _, physical := range group {
+			l := logicalKnobs[i]
+			i++
+			d.knobToLogical[physical] = l
+			d.knobToPhysical[l] = physical
+			d.buttonToLogical[Button(physical)] = Button(l)
+			d.buttonToPhysical[Button(l)] = Button(physical)
+		}
+	}
+
+	buttons := append([]Button{}, d.model.buttons...)
+	buttonPos := map[Button]image.Point{}
+	for i, b := range buttons {
+		buttonPos[b] = logical((2*i+1)*w/(2*len(buttons)), h-1)
+	}
+	sort.Slice(buttons, func(a, b int) bool {
+		return readsBefore(buttonPos[buttons[a]], buttonPos[buttons[b]])
+	})
+	for i, physical := range buttons {
+		l := d.model.buttons[i]
+		d.buttonToLogical[physical] = l
+		d.buttonToPhysical[l] = physical
+	}
+}
+
+// logicalKnob maps a knob number reported by the hardware onto its
+// logical number in the current orientation.
+func (d *Device) logicalKnob(k Knob) Knob {
+	if l, ok := d.knobToLogical[k]; ok {
+		return l
+	}
+	return k
+}
+
+// logicalButton maps a button number reported by the hardware onto
+// its logical number in the current orientation.
+func (d *Device) logicalButton(b Button) Button {
+	if l, ok := d.buttonToLogical[b]; ok {
+		return l
+	}
+	return b
+}
+
+// physicalButton maps a logical button number back onto the button
+// number used by the hardware.
+func (d *Device) physicalButton(b Button) Button {
+	if p, ok := d.buttonToPhysical[b]; ok {
+		return p
+	}
+	return b
+}
@@ -0,0 +1,199 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+var allOrientations = []Orientation{
+	Rotate0, Rotate90, Rotate180, Rotate270,
+	Rotate0 | Mirrored, Rotate90 | Mirrored, Rotate180 | Mirrored, Rotate270 | Mirrored,
+}
+
+func TestOrientationRoundTrip(t *testing.T) {
+	const w, h = 6, 4
+	for _, o := range allOrientations {
+		lw, lh := o.Size(w, h)
+		seen := map[image.Point]bool{}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				lx, ly := o.ToLogical(x, y, w, h)
+				if lx < 0 || lx >= lw || ly < 0 || ly >= lh {
+					t.Errorf("%v: ToLogical(%d, %d) = %d, %d, outside of %dx%d", o, x, y, lx, ly, lw, lh)
+				}
+				if seen[image.Pt(lx, ly)] {
+					t.Errorf("%v: ToLogical maps two pixels onto %d, %d", o, lx, ly)
+				}
+				seen[image.Pt(lx, ly)] = true
+				if px, py := o.ToPhysical(lx, ly, w, h); px != x || py != y {
+					t.Errorf("%v: ToPhysical(ToLogical(%d, %d)) = %d, %d", o, x, y, px, py)
+				}
+			}
+		}
+	}
+}
+
+func TestOrientationCorners(t *testing.T) {
+	// Where the physical top-left pixel of a 6x4 area ends up.
+	tests := []struct {
+		o      Orientation
+		x, y   int
+		lw, lh int
+	}{
+		{Rotate0, 0, 0, 6, 4},
+		{Rotate90, 3, 0, 4, 6},
+		{Rotate180, 5, 3, 6, 4},
+		{Rotate270, 0, 5, 4, 6},
+		{Rotate0 | Mirrored, 5, 0, 6, 4},
+		{Rotate90 | Mirrored, 0, 0, 4, 6},
+		{Rotate180 | Mirrored, 0, 3, 6, 4},
+		{Rotate270 | Mirrored, 3, 5, 4, 6},
+	}
+	for _, tt := range tests {
+		if x, y := tt.o.ToLogical(0, 0, 6, 4); x != tt.x || y != tt.y {
+			t.Errorf("%v: ToLogical(0, 0) = %d, %d, want %d, %d", tt.o, x, y, tt.x, tt.y)
+		}
+		if lw, lh := tt.o.Size(6, 4); lw != tt.lw || lh != tt.lh {
+			t.Errorf("%v: Size(6, 4) = %d, %d, want %d, %d", tt.o, lw, lh, tt.lw, tt.lh)
+		}
+	}
+}
+
+func TestOrientationRects(t *testing.T) {
+	const w, h = 480, 270
+	rects := []image.Rectangle{
+		image.Rect(0, 0, 60, 270),
+		image.Rect(60, 0, 150, 90),
+		image.Rect(330, 180, 420, 270),
+		image.Rect(0, 0, 480, 270),
+		image.Rect(10, 20, 11, 21),
+	}
+	for _, o := range allOrientations {
+		for _, r := range rects {
+			l := o.RectToLogical(r, w, h)
+			if l.Dx()*l.Dy() != r.Dx()*r.Dy() {
+				t.Errorf("%v: RectToLogical(%v) = %v, which has a different area", o, r, l)
+			}
+			if p := o.RectToPhysical(l, w, h); p != r {
+				t.Errorf("%v: RectToPhysical(RectToLogical(%v)) = %v", o, r, p)
+			}
+		}
+		if r := o.RectToPhysical(image.Rectangle{}, w, h); !r.Empty() {
+			t.Errorf("%v: RectToPhysical of an empty rectangle = %v", o, r)
+		}
+	}
+}
+
+func TestTransformImage(t *testing.T) {
+	const w, h = 8, 6
+	for _, o := range allOrientations {
+		// Give every pixel of a 3x2 logical area its own color,
+		// and check that each one lands on the right physical
+		// pixel.
+		r := image.Rect(1, 2, 4, 4)
+		im := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		for y := 0; y < r.Dy(); y++ {
+			for x := 0; x < r.Dx(); x++ {
+				im.Set(x, y, color.RGBA{uint8(x), uint8(y), 0, 255})
+			}
+		}
+
+		out, pr := o.transformImage(im, r, w, h)
+		if pr != o.RectToPhysical(r, w, h) {
+			t.Errorf("%v: transformImage returned %v, want %v", o, pr, o.RectToPhysical(r, w, h))
+		}
+		ob := out.Bounds()
+		if ob.Dx() != pr.Dx() || ob.Dy() != pr.Dy() {
+			t.Errorf("%v: transformed image is %v, want the size of %v", o, ob, pr)
+			continue
+		}
+		for py := pr.Min.Y; py < pr.Max.Y; py++ {
+			for px := pr.Min.X; px < pr.Max.X; px++ {
+				lx, ly := o.ToLogical(px, py, w, h)
+				want := color.RGBA{uint8(lx - r.Min.X), uint8(ly - r.Min.Y), 0, 255}
+				got := out.At(ob.Min.X+px-pr.Min.X, ob.Min.Y+py-pr.Min.Y)
+				if color.RGBAModel.Convert(got) != want {
+					t.Errorf("%v: physical pixel %d, %d is %v, want %v", o, px, py, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestOrientationRemap(t *testing.T) {
+	tests := []struct {
+		o       Orientation
+		knobs   map[Knob]Knob
+		buttons map[Button]Button
+	}{
+		{
+			o:       Rotate0,
+			knobs:   map[Knob]Knob{Knob1: Knob1, Knob3: Knob3, Knob4: Knob4, Knob6: Knob6},
+			buttons: map[Button]Button{Button0: Button0, Button7: Button7},
+		},
+		{
+			o:       Rotate180,
+			knobs:   map[Knob]Knob{Knob1: Knob6, Knob2: Knob5, Knob3: Knob4, Knob4: Knob3, Knob6: Knob1},
+			buttons: map[Button]Button{Button0: Button7, Button3: Button4, Button7: Button0, KnobButton6: KnobButton1},
+		},
+		{
+			o:       Rotate0 | Mirrored,
+			knobs:   map[Knob]Knob{Knob1: Knob4, Knob3: Knob6, Knob4: Knob1, Knob6: Knob3},
+			buttons: map[Button]Button{Button0: Button7, Button7: Button0, KnobButton1: KnobButton4},
+		},
+		{
+			o:       Rotate180 | Mirrored,
+			knobs:   map[Knob]Knob{Knob1: Knob3, Knob3: Knob1, Knob4: Knob6, Knob5: Knob5},
+			buttons: map[Button]Button{Button0: Button0, Button7: Button7, KnobButton1: KnobButton3},
+		},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		d.SetOrientation(tt.o)
+		for physical, want := range tt.knobs {
+			if got := d.logicalKnob(physical); got != want {
+				t.Errorf("%v: logicalKnob(%v) = %v, want %v", tt.o, physical, got, want)
+			}
+		}
+		for physical, want := range tt.buttons {
+			if got := d.logicalButton(physical); got != want {
+				t.Errorf("%v: logicalButton(%v) = %v, want %v", tt.o, physical, got, want)
+			}
+			if got := d.physicalButton(want); got != physical {
+				t.Errorf("%v: physicalButton(%v) = %v, want %v", tt.o, want, got, physical)
+			}
+		}
+	}
+}
+
+func TestOrientationTouchKeys(t *testing.T) {
+	// Touch1 is always the top-left key as the user sees it, and
+	// the side strips follow the knobs.
+	tests := []struct {
+		o           Orientation
+		touch1      image.Rectangle
+		left, right image.Rectangle
+	}{
+		{Rotate0, image.Rect(60, 0, 150, 90), image.Rect(0, 0, 60, 270), image.Rect(420, 0, 480, 270)},
+		{Rotate180, image.Rect(60, 0, 150, 90), image.Rect(0, 0, 60, 270), image.Rect(420, 0, 480, 270)},
+		{Rotate90, image.Rect(0, 60, 90, 150), image.Rect(0, 0, 270, 60), image.Rect(0, 420, 270, 480)},
+		{Rotate270 | Mirrored, image.Rect(0, 60, 90, 150), image.Rect(0, 0, 270, 60), image.Rect(0, 420, 270, 480)},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		d.SetOrientation(tt.o)
+		if r := d.TouchKeyRect(Touch1); r != tt.touch1 {
+			t.Errorf("%v: Touch1 is at %v, want %v", tt.o, r, tt.touch1)
+		}
+		if r := d.TouchKeyRect(TouchLeft); r != tt.left {
+			t.Errorf("%v: TouchLeft is at %v, want %v", tt.o, r, tt.left)
+		}
+		if r := d.TouchKeyRect(TouchRight); r != tt.right {
+			t.Errorf("%v: TouchRight is at %v, want %v", tt.o, r, tt.right)
+		}
+		if n := len(d.TouchButtons()); n != 12 {
+			t.Errorf("%v: %d touch keys, want 12", tt.o, n)
+		}
+	}
+}
@@ -0,0 +1,600 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Page is one layout of the device's controls: the widgets on its
+// touch keys and side strips, and the callbacks for its buttons,
+// knobs, and touches.  A Page's bindings are only active while it's
+// the current page of its Profile.
+type Page struct {
+	Name    string
+	profile *Profile
+
+	mutex sync.Mutex
+	// entries are kept in the order they were added, so that
+	// they're bound in that order each time the page is shown.
+	entries []*pageEntry
+	widgets map[TouchButton]Widget
+	knobs   map[Knob]Widget
+	// active is true while the page's bindings are bound.  It's
+	// changed along with them, so that bindings added while the
+	// page is being switched to are bound exactly once.
+	active bool
+}
+
+// pageEntry is a single binding on a Page.  bind is called to
+// activate it, and active holds the resulting Binding while the page
+// is shown.
+type pageEntry struct {
+	bind   func(*Device) *Binding
+	active *Binding
+}
+
+// PageChangeFunc is a function signature used for callbacks when a
+// Profile switches pages.  old is nil for the first page shown.
+type PageChangeFunc func(old, new *Page)
+
+// Profile is a set of Pages, of which one is shown at a time.  Pages
+// can be switched directly with Show, or like folders with Push and
+// Back, which keep a stack of the pages to go back to.  Switching
+// pages deactivates the old page's bindings, activates the new one's,
+// and redraws the touch keys.
+type Profile struct {
+	device    *Device
+	container *WidgetContainer
+
+	// switching is held for the whole of a page switch, so that
+	// one switch's deactivate and activate steps can't interleave
+	// with another's.  It's taken before the mutex.
+	switching sync.Mutex
+
+	mutex   sync.Mutex
+	pages   []*Page
+	current *Page
+	stack   []*Page
+	changes handlerList[struct{}, PageChangeFunc]
+	values  map[string]*Watched[float64]
+}
+
+// NewProfile creates an empty Profile for a device.
+func NewProfile(d *Device) *Profile {
+	return &Profile{
+		device:    d,
+		container: NewWidgetContainer(d),
+		values:    map[string]*Watched[float64]{},
+	}
+}
+
+// Value returns the named value shared by the profile's pages, or
+// nil.
+func (p *Profile) Value(name string) *Watched[float64] {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.values[name]
+}
+
+// SetValue adds a named value to the profile, so that its pages can
+// share it.
+func (p *Profile) SetValue(name string, w *Watched[float64]) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.values[name] = w
+}
+
+// AddPage adds a new, empty page to the profile.  If a page with the
+// same name already exists, then it's returned instead.  Nothing is
+// shown until Show is called.
+func (p *Profile) AddPage(name string) *Page {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if page := p.page(name); page != nil {
+		return page
+	}
+	page := &Page{
+		Name:    name,
+		profile: p,
+		widgets: map[TouchButton]Widget{},
+		knobs:   map[Knob]Widget{},
+	}
+	p.pages = append(p.pages, page)
+	return page
+}
+
+// RemovePage removes the named page from the profile and its back
+// stack.  If it's the current page, then the first remaining page is
+// shown instead.  It returns false if there's no such page.
+func (p *Profile) RemovePage(name string) bool {
+	p.switching.Lock()
+	p.mutex.Lock()
+	page := p.page(name)
+	if page == nil {
+		p.mutex.Unlock()
+		p.switching.Unlock()
+		return false
+	}
+	p.pages = slices.DeleteFunc(p.pages, func(pg *Page) bool { return pg == page })
+	p.stack = slices.DeleteFunc(p.stack, func(pg *Page) bool { return pg == page })
+	current := p.current == page
+	var next *Page
+	if current && len(p.pages) > 0 {
+		next = p.pages[0]
+	}
+	p.mutex.Unlock()
+
+	notify := func() {}
+	switch {
+	case !current:
+	case next != nil:
+		notify = p.switchTo(next)
+	default:
+		p.mutex.Lock()
+		p.current = nil
+		p.mutex.Unlock()
+		page.deactivate()
+		page.draw(nil)
+	}
+	p.switching.Unlock()
+	notify()
+	return true
+}
+
+// orderPages puts the named pages first, in the given order, for
+// Next and Previous.  Other pages keep their order after them.
+func (p *Profile) orderPages(names []string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	rank := func(pg *Page) int {
+		if i := slices.Index(names, pg.Name); i >= 0 {
+			return i
+		}
+		return len(names)
+	}
+	slices.SortStableFunc(p.pages, func(a, b *Page) int { return rank(a) - rank(b) })
+}
+
+// Page returns the page with the given name, or nil.
+func (p *Profile) Page(name string) *Page {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.page(name)
+}
+
+// page finds a page by name.  The caller must hold the mutex.
+func (p *Profile) page(name string) *Page {
+	for _, page := range p.pages {
+		if page.Name == name {
+			return page
+		}
+	}
+	return nil
+}
+
+// Pages returns all of the profile's pages, in the order used by Next
+// and Previous.
+func (p *Profile) Pages() []*Page {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]*Page{}, p.pages...)
+}
+
+// Current returns the page being shown, or nil if none has been
+// shown yet.
+func (p *Profile) Current() *Page {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.current
+}
+
+// OnPageChange adds a callback for page switches.
+func (p *Profile) OnPageChange(f PageChangeFunc) *Binding {
+	return p.changes.addAny(f)
+}
+
+// Show switches to the named page and clears the back stack.
+func (p *Profile) Show(name string) error {
+	return p.navigate(name, func() { p.stack = nil })
+}
+
+// Push switches to the named page, like opening a folder.  Back
+// returns to the page that was shown before.
+func (p *Profile) Push(name string) error {
+	return p.navigate(name, func() {
+		if p.current != nil {
+			p.stack = append(p.stack, p.current)
+		}
+	})
+}
+
+// Back returns to the page shown before the most recent Push.  It
+// returns false if the back stack is empty.
+func (p *Profile) Back() bool {
+	p.switching.Lock()
+	p.mutex.Lock()
+	if len(p.stack) == 0 {
+		p.mutex.Unlock()
+		p.switching.Unlock()
+		return false
+	}
+	page := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+	p.mutex.Unlock()
+
+	notify := p.switchTo(page)
+	p.switching.Unlock()
+	notify()
+	return true
+}
+
+// Next switches to the page after the current one, wrapping around
+// at the end.  The back stack is cleared.
+func (p *Profile) Next() {
+	p.step(1)
+}
+
+// Previous switches to the page before the current one, wrapping
+// around at the start.  The back stack is cleared.
+func (p *Profile) Previous() {
+	p.step(-1)
+}
+
+func (p *Profile) step(n int) {
+	p.switching.Lock()
+	p.mutex.Lock()
+	if len(p.pages) == 0 {
+		p.mutex.Unlock()
+		p.switching.Unlock()
+		return
+	}
+	i := 0
+	for j, page := range p.pages {
+		if page == p.current {
+			i = j
+		}
+	}
+	page := p.pages[((i+n)%len(p.pages)+len(p.pages))%len(p.pages)]
+	p.stack = nil
+	p.mutex.Unlock()
+
+	notify := p.switchTo(page)
+	p.switching.Unlock()
+	notify()
+}
+
+// navigate looks up a page, updates the back stack with f, and
+// switches to it.
+func (p *Profile) navigate(name string, f func()) error {
+	p.switching.Lock()
+	p.mutex.Lock()
+	page := p.page(name)
+	if page == nil {
+		p.mutex.Unlock()
+		p.switching.Unlock()
+		return fmt.Errorf("no page named %q", name)
+	}
+	if page != p.current {
+		f()
+	}
+	p.mutex.Unlock()
+
+	notify := p.switchTo(page)
+	p.switching.Unlock()
+	notify()
+	return nil
+}
+
+// switchTo makes page the current page, swapping bindings and
+// widgets.  The caller must hold switching, and should call the
+// returned function, which calls the OnPageChange callbacks, once
+// it's released.
+func (p *Profile) switchTo(page *Page) func() {
+	p.mutex.Lock()
+	old := p.current
+	if old == page {
+		p.mutex.Unlock()
+		return func() {}
+	}
+	p.current = page
+	p.mutex.Unlock()
+
+	slog.Info("Switching page", "page", page.Name)
+	if old != nil {
+		old.deactivate()
+	}
+	page.activate()
+
+	return func() {
+		for _, f := range p.changes.get(struct{}{}) {
+			f := f
+			p.device.safely(func() { f(old, page) })
+		}
+	}
+}
+
+// BindPageButton makes a button show the named page when pressed.
+func (p *Profile) BindPageButton(b Button, name string) *Binding {
+	return p.device.BindButton(b, func(Button, ButtonState) {
+		if err := p.Show(name); err != nil {
+			slog.Warn("Unable to show page", "err", err)
+		}
+	})
+}
+
+// BindBackButton makes a button go back to the previous page when
+// pressed.
+func (p *Profile) BindBackButton(b Button) *Binding {
+	return p.device.BindButton(b, func(Button, ButtonState) { p.Back() })
+}
+
+// BindSwipes makes swiping left or right across the main display
+// switch to the next or previous page.  The swipes are recognized by
+// a TouchRegion covering the display, placed below any other regions.
+func (p *Profile) BindSwipes() *Binding {
+	disp := p.device.GetDisplay("main")
+	if disp == nil {
+		return newBinding(nil)
+	}
+	const name = "profile-swipes"
+	r := disp.AddTouchRegion(name, image.Rect(0, 0, disp.Width(), disp.Height()), nil)
+	r.SetZ(-1 << 20)
+	r.OnGesture(func(e GestureEvent) {
+		if e.Type != GestureSwipe {
+			return
+		}
+		switch e.Direction {
+		case SwipeLeft:
+			p.Next()
+		case SwipeRight:
+			p.Previous()
+		}
+	})
+	return newBinding(func() { disp.RemoveTouchRegion(name) })
+}
+
+// Close deactivates the current page and removes its widgets.
+func (p *Profile) Close() {
+	p.switching.Lock()
+	defer p.switching.Unlock()
+	p.mutex.Lock()
+	page := p.current
+	p.current = nil
+	p.stack = nil
+	p.mutex.Unlock()
+
+	if page != nil {
+		page.deactivate()
+	}
+	p.container.Close()
+}
+
+// add records a binding on the page, activating it immediately if the
+// page is being shown.
+func (pg *Page) add(bind func(*Device) *Binding) *Binding {
+	pg.mutex.Lock()
+	e := &pageEntry{bind: bind}
+	pg.entries = append(pg.entries, e)
+	active := pg.active
+	pg.mutex.Unlock()
+	if active {
+		pg.bindEntry(e)
+	}
+
+	return newBinding(func() {
+		pg.mutex.Lock()
+		pg.entries = slices.DeleteFunc(pg.entries, func(o *pageEntry) bool { return o == e })
+		b := e.active
+		e.active = nil
+		pg.mutex.Unlock()
+		b.Unbind()
+	})
+}
+
+// bindEntry activates an entry, without holding the mutex while its
+// bind function runs.  If the page was hidden, the entry removed, or
+// the entry activated by someone else meanwhile, the new Binding is
+// unbound again, so that each entry is bound at most once.
+func (pg *Page) bindEntry(e *pageEntry) {
+	b := e.bind(pg.profile.device)
+
+	pg.mutex.Lock()
+	keep := pg.active && e.active == nil && slices.Contains(pg.entries, e)
+	if keep {
+		e.active = b
+	}
+	pg.mutex.Unlock()
+
+	if !keep {
+		b.Unbind()
+	}
+}
+
+// BindButton adds a callback for a button press while the page is
+// shown.
+func (pg *Page) BindButton(b Button, f ButtonFunc) *Binding {
+	return pg.add(func(d *Device) *Binding { return d.BindButton(b, f) })
+}
+
+// BindButtonUp adds a callback for a button release while the page
+// is shown.
+func (pg *Page) BindButtonUp(b Button, f ButtonFunc) *Binding {
+	return pg.add(func(d *Device) *Binding { return d.BindButtonUp(b, f) })
+}
+
+// BindKnob adds a callback for knob turns while the page is shown.
+func (pg *Page) BindKnob(k Knob, f KnobFunc) *Binding {
+	return pg.add(func(d *Device) *Binding { return d.BindKnob(k, f) })
+}
+
+// BindTouch adds a callback for TouchButton presses while the page
+// is shown.
+func (pg *Page) BindTouch(b TouchButton, f TouchFunc) *Binding {
+	return pg.add(func(d *Device) *Binding { return d.BindTouch(b, f) })
+}
+
+// BindTouchUp adds a callback for TouchButton releases while the
+// page is shown.
+func (pg *Page) BindTouchUp(b TouchButton, f TouchFunc) *Binding {
+	return pg.add(func(d *Device) *Binding { return d.BindTouchUp(b, f) })
+}
+
+// Bind adds any binding to the page.  bind is called each time the
+// page is shown, and the Binding it returns is unbound when the page
+// is hidden.  This allows things like Watched.BindKnob to be used
+// per page.
+func (pg *Page) Bind(bind func(*Device) *Binding) *Binding {
+	return pg.add(bind)
+}
+
+// SetWidget places a widget on a TouchButton on this page.  Passing
+// a nil Widget removes it.
+func (pg *Page) SetWidget(b TouchButton, w Widget) {
+	pg.mutex.Lock()
+	if w == nil {
+		delete(pg.widgets, b)
+	} else {
+		pg.widgets[b] = w
+	}
+	active := pg.active
+	pg.mutex.Unlock()
+
+	if active {
+		c := pg.profile.container
+		if w == nil {
+			c.Remove(b)
+			pg.profile.device.clearTouchKey(b)
+		} else {
+			c.Add(b, w)
+		}
+	}
+}
+
+// Widget returns the widget on a TouchButton on this page, or nil.
+func (pg *Page) Widget(b TouchButton) Widget {
+	pg.mutex.Lock()
+	defer pg.mutex.Unlock()
+	return pg.widgets[b]
+}
+
+// AttachKnob sends turns and clicks of a knob to a widget while the
+// page is shown.  Passing a nil Widget detaches the knob.
+func (pg *Page) AttachKnob(k Knob, w Widget) {
+	pg.mutex.Lock()
+	if w == nil {
+		delete(pg.knobs, k)
+	} else {
+		pg.knobs[k] = w
+	}
+	active := pg.active
+	pg.mutex.Unlock()
+
+	if active {
+		pg.profile.container.AttachKnob(k, w)
+	}
+}
+
+// redraw draws the current page's widgets again, and clears its
+// empty keys.
+func (p *Profile) redraw() {
+	if page := p.Current(); page != nil {
+		page.mutex.Lock()
+		widgets := maps.Clone(page.widgets)
+		page.mutex.Unlock()
+		page.draw(widgets)
+	}
+}
+
+// redrawKey draws the widget on a TouchButton again, or clears the
+// key if there's no widget on it.
+func (p *Profile) redrawKey(b TouchButton) {
+	if w := p.container.Widget(b); w != nil {
+		p.container.draw(b, w)
+	} else {
+		p.device.clearTouchKey(b)
+	}
+}
+
+// activate binds the page's callbacks and draws its widgets.  Keys
+// without a widget are cleared.
+func (pg *Page) activate() {
+	c := pg.profile.container
+
+	pg.mutex.Lock()
+	pg.active = true
+	var entries []*pageEntry
+	for _, e := range pg.entries {
+		if e.active == nil {
+			entries = append(entries, e)
+		}
+	}
+	widgets := maps.Clone(pg.widgets)
+	knobs := maps.Clone(pg.knobs)
+	pg.mutex.Unlock()
+
+	for _, e := range entries {
+		pg.bindEntry(e)
+	}
+	for k, w := range knobs {
+		c.AttachKnob(k, w)
+	}
+	pg.draw(widgets)
+}
+
+// draw places widgets on the touch keys and side strips, and clears
+// the ones without a widget.
+func (pg *Page) draw(widgets map[TouchButton]Widget) {
+	d := pg.profile.device
+	c := pg.profile.container
+
+	keys := append(d.TouchButtons(), TouchLeft, TouchRight, TouchWheel)
+	for _, b := range keys {
+		if w := widgets[b]; w != nil {
+			c.Add(b, w)
+		} else {
+			c.Remove(b)
+			d.clearTouchKey(b)
+		}
+	}
+}
+
+// deactivate unbinds the page's callbacks and detaches its widgets.
+func (pg *Page) deactivate() {
+	c := pg.profile.container
+
+	pg.mutex.Lock()
+	pg.active = false
+	var bindings []*Binding
+	for _, e := range pg.entries {
+		bindings = append(bindings, e.active)
+		e.active = nil
+	}
+	widgets := maps.Clone(pg.widgets)
+	knobs := maps.Clone(pg.knobs)
+	pg.mutex.Unlock()
+
+	for _, b := range bindings {
+		b.Unbind()
+	}
+	for b := range widgets {
+		c.Remove(b)
+	}
+	for k := range knobs {
+		c.AttachKnob(k, nil)
+	}
+}
+
+// clearTouchKey fills a TouchButton's area with black.  Keys that
+// the model doesn't have are skipped.
+func (d *Device) clearTouchKey(b TouchButton) {
+	r := d.TouchKeyRect(b)
+	if r.Empty() {
+		return
+	}
+	d.DrawTouchKey(b, solidImage(r.Size(), color.Black))
+}
@@ -0,0 +1,143 @@
+package loupedeck
+
+import (
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestPageBindOrder(t *testing.T) {
+	d := newTestDevice(t, "0004")
+	p := NewProfile(d)
+	defer p.Close()
+	page := p.AddPage("home")
+	p.AddPage("other")
+
+	var mutex sync.Mutex
+	var order []int
+	bindings := make([]*Binding, 10)
+	for i := range bindings {
+		i := i
+		bindings[i] = page.Bind(func(*Device) *Binding {
+			mutex.Lock()
+			order = append(order, i)
+			mutex.Unlock()
+			return newBinding(nil)
+		})
+	}
+	bindings[3].Unbind()
+	want := []int{0, 1, 2, 4, 5, 6, 7, 8, 9}
+
+	for _, name := range []string{"home", "other", "home"} {
+		order = nil
+		if err := p.Show(name); err != nil {
+			t.Fatal(err)
+		}
+		if name == "home" && !slices.Equal(order, want) {
+			t.Errorf("bindings were bound in order %v, want %v", order, want)
+		}
+	}
+}
+
+func TestPageAddWhileSwitching(t *testing.T) {
+	d := newTestDevice(t, "0004")
+	p := NewProfile(d)
+	defer p.Close()
+	home := p.AddPage("home")
+	p.AddPage("other")
+
+	for i := 0; i < 20; i++ {
+		var mutex sync.Mutex
+		bound := 0
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Show("home")
+		}()
+		b := home.Bind(func(*Device) *Binding {
+			mutex.Lock()
+			bound++
+			mutex.Unlock()
+			return newBinding(func() {
+				mutex.Lock()
+				bound--
+				mutex.Unlock()
+			})
+		})
+		wg.Wait()
+
+		mutex.Lock()
+		if bound != 1 {
+			t.Fatalf("binding is bound %d times while its page is shown, want 1", bound)
+		}
+		mutex.Unlock()
+
+		p.Show("other")
+		b.Unbind()
+		mutex.Lock()
+		if bound != 0 {
+			t.Fatalf("binding is bound %d times after its page was hidden, want 0", bound)
+		}
+		mutex.Unlock()
+	}
+}
+
+func TestPageConcurrentSwitches(t *testing.T) {
+	d := newTestDevice(t, "0004")
+	p := NewProfile(d)
+	defer p.Close()
+
+	var mutex sync.Mutex
+	live := map[string]int{}
+	for _, name := range []string{"one", "two", "three"} {
+		name := name
+		page := p.AddPage(name)
+		page.Bind(func(*Device) *Binding {
+			// Bind functions may use their page.
+			page.Widget(Touch1)
+			mutex.Lock()
+			live[name]++
+			mutex.Unlock()
+			return newBinding(func() {
+				mutex.Lock()
+				live[name]--
+				mutex.Unlock()
+			})
+		})
+	}
+
+	switches := []func(){
+		func() { p.Show("one") },
+		func() { p.Push("two") },
+		func() { p.Back() },
+		func() { p.Next() },
+		func() { p.Previous() },
+		func() { p.Show("three") },
+	}
+	var wg sync.WaitGroup
+	for _, f := range switches {
+		f := f
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 5; i++ {
+				f()
+			}
+		}()
+	}
+	wg.Wait()
+
+	current := p.Current().Name
+	mutex.Lock()
+	defer mutex.Unlock()
+	for name, n := range live {
+		want := 0
+		if name == current {
+			want = 1
+		}
+		if n != want {
+			t.Errorf("page %q has %d live bindings with %q shown, want %d", name, n, current, want)
+		}
+	}
+}
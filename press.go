@@ -0,0 +1,234 @@
+package loupedeck
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ButtonBehavior holds the timing used for long-presses,
+// double-presses, and auto-repeat on a Button.
+type ButtonBehavior struct {
+	// LongPress is how long a button must be held to count as a
+	// long-press.
+	LongPress time.Duration
+	// DoublePress is how long to wait for a second press.
+	DoublePress time.Duration
+	// RepeatDelay is how long a button must be held before it
+	// starts repeating.  Zero disables auto-repeat.
+	RepeatDelay time.Duration
+	// RepeatRate is the time between repeats.
+	RepeatRate time.Duration
+}
+
+// DefaultButtonBehavior is used for buttons that haven't been
+// configured with SetButtonBehavior.  It doesn't auto-repeat.
+var DefaultButtonBehavior = ButtonBehavior{
+	LongPress:   600 * time.Millisecond,
+	DoublePress: 300 * time.Millisecond,
+}
+
+// pressState tracks the long-press, double-press, and repeat state
+// of a single button.
+type pressState struct {
+	down bool
+	// gen is incremented on every press and release, so that
+	// timers from earlier presses can tell they're stale.
+	gen   int
+	long  bool
+	timer *time.Timer
+	// pending is a short press waiting to see if it becomes a
+	// double-press.
+	pending *time.Timer
+	// consumed is set when the press was the second half of a
+	// double-press, so its release is dropped.
+	consumed bool
+	table    *bindingTable
+}
+
+// pressTable holds the button behaviors for a Device.
+type pressTable struct {
+	mutex       sync.Mutex
+	behaviors   map[Button]ButtonBehavior
+	states      map[Button]*pressState
+	longPress   handlerList[Button, ButtonFunc]
+	doublePress handlerList[Button, ButtonFunc]
+}
+
+// SetButtonBehavior sets the long-press, double-press, and
+// auto-repeat timing for a button.
+func (d *Device) SetButtonBehavior(b Button, bb ButtonBehavior) {
+	d.presses.mutex.Lock()
+	defer d.presses.mutex.Unlock()
+	d.presses.behaviors[b] = bb
+}
+
+// SetButtonRepeat makes a button auto-repeat while held: after
+// delay, the button's ButtonDown callbacks are called again every
+// rate until it's released.  A zero delay turns auto-repeat off.
+// Buttons with long-press callbacks don't auto-repeat.
+func (d *Device) SetButtonRepeat(b Button, delay, rate time.Duration) {
+	d.presses.mutex.Lock()
+	defer d.presses.mutex.Unlock()
+	bb := d.presses.behavior(b)
+	bb.RepeatDelay = delay
+	bb.RepeatRate = rate
+	d.presses.behaviors[b] = bb
+}
+
+// BindButtonLongPress adds a callback for holding a button down.
+// The callback is called as soon as the button has been held for
+// the button's LongPress duration, and the button's normal ButtonDown
+// and ButtonUp callbacks are not called for that press.  For short
+// presses, the normal ButtonDown callbacks are delayed until the
+// button is released.
+func (d *Device) BindButtonLongPress(b Button, f ButtonFunc) *Binding {
+	return d.presses.longPress.add(b, f)
+}
+
+// BindButtonDoublePress adds a callback for pressing a button twice
+// in quick succession.  Once a button has a double-press callback,
+// single presses are delayed by the button's DoublePress duration to
+// see if a second press follows.
+func (d *Device) BindButtonDoublePress(b Button, f ButtonFunc) *Binding {
+	return d.presses.doublePress.add(b, f)
+}
+
+// behavior returns the behavior for a button.  The caller must hold
+// the mutex.
+func (p *pressTable) behavior(b Button) ButtonBehavior {
+	if bb, ok := p.behaviors[b]; ok {
+		return bb
+	}
+	return DefaultButtonBehavior
+}
+
+// state returns the press state for a button, creating it if
+// needed.  The caller must hold the mutex.
+func (p *pressTable) state(b Button) *pressState {
+	s := p.states[b]
+	if s == nil {
+		s = &pressState{}
+		p.states[b] = s
+	}
+	return s
+}
+
+// pressButton applies long-press, double-press, and auto-repeat to
+// a button press or release, and then calls the callbacks in t.
+func (d *Device) pressButton(t *bindingTable, b Button, state ButtonState) {
+	p := &d.presses
+	p.mutex.Lock()
+	bb := p.behavior(b)
+	long := bb.LongPress > 0 && p.longPress.has(b)
+	double := bb.DoublePress > 0 && p.doublePress.has(b)
+	s := p.state(b)
+	s.gen++
+	gen := s.gen
+
+	if state == ButtonDown {
+		s.down = true
+		s.long = false
+		s.table = t
+
+		if double && s.pending != nil && s.pending.Stop() {
+			s.pending = nil
+			s.consumed = true
+			p.mutex.Unlock()
+			slog.Info("Button double-press", "button", b)
+			d.dispatchPress(&p.doublePress, b)
+			return
+		}
+		s.consumed = false
+
+		if long {
+			s.timer = time.AfterFunc(bb.LongPress, func() { d.longPressed(b, gen) })
+		}
+		if long || double {
+			// Wait for the release to decide what this was.
+			p.mutex.Unlock()
+			return
+		}
+		if bb.RepeatDelay > 0 {
+			s.timer = time.AfterFunc(bb.RepeatDelay, func() { d.repeatButton(b, gen, bb.RepeatRate) })
+		}
+		p.mutex.Unlock()
+		d.dispatchButton(t, b, ButtonDown)
+		return
+	}
+
+	s.down = false
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.consumed || s.long {
+		p.mutex.Unlock()
+		return
+	}
+	if double {
+		var pending *time.Timer
+		pending = time.AfterFunc(bb.DoublePress, func() {
+			p.mutex.Lock()
+			if s.pending == pending {
+				s.pending = nil
+			}
+			p.mutex.Unlock()
+			d.dispatchButton(t, b, ButtonDown)
+			d.dispatchButton(t, b, ButtonUp)
+		})
+		s.pending = pending
+		p.mutex.Unlock()
+		return
+	}
+	p.mutex.Unlock()
+
+	if long {
+		d.dispatchButton(t, b, ButtonDown)
+	}
+	d.dispatchButton(t, b, ButtonUp)
+}
+
+// longPressed is called from a timer when a button has been held
+// long enough to count as a long-press.
+func (d *Device) longPressed(b Button, gen int) {
+	p := &d.presses
+	p.mutex.Lock()
+	s := p.state(b)
+	if s.gen != gen || !s.down {
+		p.mutex.Unlock()
+		return
+	}
+	s.long = true
+	p.mutex.Unlock()
+
+	slog.Info("Button long-press", "button", b)
+	d.dispatchPress(&p.longPress, b)
+}
+
+// repeatButton is called from a timer while a button with
+// auto-repeat is held.
+func (d *Device) repeatButton(b Button, gen int, rate time.Duration) {
+	p := &d.presses
+	p.mutex.Lock()
+	s := p.state(b)
+	if s.gen != gen || !s.down {
+		p.mutex.Unlock()
+		return
+	}
+	t := s.table
+	if rate > 0 {
+		s.timer = time.AfterFunc(rate, func() { d.repeatButton(b, gen, rate) })
+	}
+	p.mutex.Unlock()
+
+	d.dispatchButton(t, b, ButtonDown)
+}
+
+// dispatchPress calls long-press or double-press callbacks.
+func (d *Device) dispatchPress(list *handlerList[Button, ButtonFunc], b Button) {
+	for _, f := range list.get(b) {
+		f := f
+		d.run(controlKey{buttonControl, uint32(b)}, func() { f(b, ButtonDown) })
+	}
+}
@@ -0,0 +1,109 @@
+package loupedeck
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// pressStep is a button press or release in a press test, followed
+// by a pause.
+type pressStep struct {
+	state ButtonState
+	wait  time.Duration
+}
+
+func TestButtonBehaviors(t *testing.T) {
+	ms := time.Millisecond
+	tap := []pressStep{{ButtonDown, 10 * ms}, {ButtonUp, 0}}
+	tests := []struct {
+		name   string
+		bb     ButtonBehavior
+		long   bool
+		double bool
+		steps  []pressStep
+		want   []string
+	}{
+		{
+			name:  "no behaviors",
+			steps: tap,
+			want:  []string{"down", "up"},
+		},
+		{
+			name:  "short press with a long-press binding",
+			bb:    ButtonBehavior{LongPress: 100 * ms},
+			long:  true,
+			steps: tap,
+			want:  []string{"down", "up"},
+		},
+		{
+			name:  "long press",
+			bb:    ButtonBehavior{LongPress: 50 * ms},
+			long:  true,
+			steps: []pressStep{{ButtonDown, 150 * ms}, {ButtonUp, 0}},
+			want:  []string{"long"},
+		},
+		{
+			name:   "single press waits for a double",
+			bb:     ButtonBehavior{DoublePress: 50 * ms},
+			double: true,
+			steps:  tap,
+			want:   []string{"down", "up"},
+		},
+		{
+			name:   "double press",
+			bb:     ButtonBehavior{DoublePress: 100 * ms},
+			double: true,
+			steps:  []pressStep{{ButtonDown, 10 * ms}, {ButtonUp, 10 * ms}, {ButtonDown, 10 * ms}, {ButtonUp, 0}},
+			want:   []string{"double"},
+		},
+		{
+			name:   "presses too far apart",
+			bb:     ButtonBehavior{DoublePress: 50 * ms},
+			double: true,
+			steps:  []pressStep{{ButtonDown, 10 * ms}, {ButtonUp, 150 * ms}, {ButtonDown, 10 * ms}, {ButtonUp, 0}},
+			want:   []string{"down", "up", "down", "up"},
+		},
+		{
+			name:  "auto-repeat",
+			bb:    ButtonBehavior{RepeatDelay: 50 * ms, RepeatRate: 40 * ms},
+			steps: []pressStep{{ButtonDown, 110 * ms}, {ButtonUp, 0}},
+			want:  []string{"down", "down", "down", "up"},
+		},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+		d.SetButtonBehavior(Button1, tt.bb)
+		var mutex sync.Mutex
+		var got []string
+		log := func(s string) func(Button, ButtonState) {
+			return func(Button, ButtonState) {
+				mutex.Lock()
+				got = append(got, s)
+				mutex.Unlock()
+			}
+		}
+		d.BindButton(Button1, log("down"))
+		d.BindButtonUp(Button1, log("up"))
+		if tt.long {
+			d.BindButtonLongPress(Button1, log("long"))
+		}
+		if tt.double {
+			d.BindButtonDoublePress(Button1, log("double"))
+		}
+
+		for _, step := range tt.steps {
+			d.pressButton(&d.bindings, Button1, step.state)
+			time.Sleep(step.wait)
+		}
+		// Wait for a delayed single press.
+		time.Sleep(tt.bb.DoublePress + 50*ms)
+
+		mutex.Lock()
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		mutex.Unlock()
+	}
+}
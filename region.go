@@ -0,0 +1,250 @@
+package loupedeck
+
+import (
+	"image"
+	"log/slog"
+)
+
+// TouchRegion is a named area of a Display that reacts to touches.
+// Regions may be rectangular or circular, and may overlap; when they
+// do, the enabled region with the highest Z wins, with ties going to
+// the most recently added region.
+type TouchRegion struct {
+	Name     string
+	display  *Display
+	bounds   image.Rectangle
+	circle   bool
+	z        int
+	seq      int
+	enabled  bool
+	f        TouchRegionFunc
+	gestures *gestureRecognizer
+}
+
+// TouchRegionEvent describes a touch on a TouchRegion.
+type TouchRegionEvent struct {
+	Region *TouchRegion
+	State  ButtonState
+	// X and Y are relative to the top-left corner of the region.
+	X, Y int
+	// DisplayX and DisplayY are relative to the top-left corner
+	// of the region's display.
+	DisplayX, DisplayY int
+}
+
+// TouchRegionFunc is a function signature used for callbacks on
+// TouchRegion events.  It's called once when a touch starts inside
+// the region and again when that touch ends, even if the finger has
+// moved outside of the region.
+type TouchRegionFunc func(TouchRegionEvent)
+
+// AddTouchRegion adds a rectangular touch region to the display.
+// The rectangle is in display coordinates, as seen in the device's
+// current orientation.  If a region with the same name already
+// exists on this display, then it is replaced.
+func (d *Display) AddTouchRegion(name string, r image.Rectangle, f TouchRegionFunc) *TouchRegion {
+	return d.addTouchRegion(&TouchRegion{
+		Name:   name,
+		bounds: r.Canon(),
+		f:      f,
+	})
+}
+
+// AddTouchCircle adds a circular touch region to the display, such
+// as a button on the CT's round "dial" display.
+func (d *Display) AddTouchCircle(name string, center image.Point, radius int, f TouchRegionFunc) *TouchRegion {
+	return d.addTouchRegion(&TouchRegion{
+		Name:   name,
+		bounds: image.Rect(center.X-radius, center.Y-radius, center.X+radius, center.Y+radius),
+		circle: true,
+		f:      f,
+	})
+}
+
+func (d *Display) addTouchRegion(r *TouchRegion) *TouchRegion {
+	d.device.regionMutex.Lock()
+	defer d.device.regionMutex.Unlock()
+
+	d.removeTouchRegion(r.Name)
+	d.device.regionSeq++
+	r.display = d
+	r.seq = d.device.regionSeq
+	r.enabled = true
+	d.regions = append(d.regions, r)
+	return r
+}
+
+// TouchRegion returns the named touch region, or nil if there isn't
+// one.
+func (d *Display) TouchRegion(name string) *TouchRegion {
+	d.device.regionMutex.RLock()
+	defer d.device.regionMutex.RUnlock()
+
+	for _, r := range d.regions {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// TouchRegions returns all of the display's touch regions.
+func (d *Display) TouchRegions() []*TouchRegion {
+	d.device.regionMutex.RLock()
+	defer d.device.regionMutex.RUnlock()
+
+	return append([]*TouchRegion{}, d.regions...)
+}
+
+// RemoveTouchRegion removes the named touch region from the display.
+func (d *Display) RemoveTouchRegion(name string) {
+	d.device.regionMutex.Lock()
+	defer d.device.regionMutex.Unlock()
+
+	d.removeTouchRegion(name)
+}
+
+func (d *Display) removeTouchRegion(name string) {
+	for i, r := range d.regions {
+		if r.Name == name {
+			d.regions = append(d.regions[:i], d.regions[i+1:]...)
+			return
+		}
+	}
+}
+
+// Display returns the display that the region is attached to.
+func (r *TouchRegion) Display() *Display {
+	return r.display
+}
+
+// Bounds returns the bounding rectangle of the region, in display
+// coordinates.
+func (r *TouchRegion) Bounds() image.Rectangle {
+	return r.bounds
+}
+
+// Contains returns true if the display coordinate p is inside the region.
+func (r *TouchRegion) Contains(p image.Point) bool {
+	if !p.In(r.bounds) {
+		return false
+	}
+	if !r.circle {
+		return true
+	}
+	radius := r.bounds.Dx() / 2
+	c := r.bounds.Min.Add(image.Pt(radius, radius))
+	dx, dy := p.X-c.X, p.Y-c.Y
+	return dx*dx+dy*dy <= radius*radius
+}
+
+// Z returns the region's stacking order.
+func (r *TouchRegion) Z() int {
+	r.display.device.regionMutex.RLock()
+	defer r.display.device.regionMutex.RUnlock()
+	return r.z
+}
+
+// SetZ sets the region's stacking order.  Regions with a higher Z
+// receive touches before regions with a lower Z.
+func (r *TouchRegion) SetZ(z int) {
+	r.display.device.regionMutex.Lock()
+	defer r.display.device.regionMutex.Unlock()
+	r.z = z
+}
+
+// Enabled returns true if the region is receiving touches.
+func (r *TouchRegion) Enabled() bool {
+	r.display.device.regionMutex.RLock()
+	defer r.display.device.regionMutex.RUnlock()
+	return r.enabled
+}
+
+// SetEnabled enables or disables the region.  Touches pass through
+// disabled regions to whatever is below them.
+func (r *TouchRegion) SetEnabled(enabled bool) {
+	r.display.device.regionMutex.Lock()
+	defer r.display.device.regionMutex.Unlock()
+	r.enabled = enabled
+}
+
+// regionAt returns the topmost enabled region under the touchscreen
+// coordinate p, along with p in that region's display coordinates.
+// The dial display is only considered when wheel is true, as it
+// has its own coordinate space.
+func (d *Device) regionAt(p image.Point, wheel bool) (*TouchRegion, image.Point) {
+	d.regionMutex.RLock()
+	defer d.regionMutex.RUnlock()
+
+	var hit *TouchRegion
+	var hitPoint image.Point
+
+	for _, disp := range d.displays {
+		if (disp.Name == "dial") != wheel {
+			continue
+		}
+		dr := disp.logicalRect()
+		if !p.In(dr) {
+			continue
+		}
+		local := p.Sub(dr.Min)
+		for _, r := range disp.regions {
+			if !r.enabled || !r.Contains(local) {
+				continue
+			}
+			if hit == nil || r.z > hit.z || (r.z == hit.z && r.seq > hit.seq) {
+				hit = r
+				hitPoint = local
+			}
+		}
+	}
+	return hit, hitPoint
+}
+
+// key returns the controlKey used to order the region's callbacks.
+func (r *TouchRegion) key() controlKey {
+	return controlKey{regionControl, uint32(r.seq)}
+}
+
+// local maps a touchscreen coordinate onto the region's display.
+func (r *TouchRegion) local(p image.Point) image.Point {
+	if r.display.Name == "dial" {
+		return p
+	}
+	return p.Sub(r.display.logicalRect().Min)
+}
+
+// dispatchTouchRegion calls the callback and gesture recognizer for
+// the region touched by a TouchSession.  The region is chosen when
+// the touch begins, and the rest of the touch is delivered to the
+// same region.
+func (d *Device) dispatchTouchRegion(phase TouchPhase, s *TouchSession, wheel bool) {
+	if phase == TouchPhaseBegin {
+		s.region, _ = d.regionAt(s.Start, wheel)
+	}
+
+	r := s.region
+	if r == nil {
+		return
+	}
+
+	if r.f != nil && phase != TouchPhaseMove {
+		state := ButtonDown
+		if phase == TouchPhaseEnd {
+			state = ButtonUp
+		}
+		local := r.local(s.Position)
+		slog.Info("Touch region", "name", r.Name, "display", r.display.Name, "state", state, "x", local.X, "y", local.Y)
+		e := TouchRegionEvent{
+			Region:   r,
+			State:    state,
+			X:        local.X - r.bounds.Min.X,
+			Y:        local.Y - r.bounds.Min.Y,
+			DisplayX: local.X,
+			DisplayY: local.Y,
+		}
+		d.run(r.key(), func() { r.f(e) })
+	}
+
+	r.recognize(phase, *s)
+}
@@ -0,0 +1,54 @@
+package loupedeck
+
+import (
+	"image"
+	"testing"
+)
+
+func TestRegionAt(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0007"})
+	main := d.GetDisplay("main")
+	main.AddTouchRegion("background", image.Rect(0, 0, 360, 270), nil)
+	main.AddTouchRegion("top", image.Rect(0, 0, 360, 90), nil)
+	low := main.AddTouchRegion("low", image.Rect(0, 0, 90, 90), nil)
+	low.SetZ(-1)
+	main.AddTouchRegion("off", image.Rect(270, 180, 360, 270), nil).SetEnabled(false)
+	d.GetDisplay("left").AddTouchRegion("strip", image.Rect(0, 0, 60, 270), nil)
+	d.GetDisplay("dial").AddTouchCircle("knob", image.Pt(120, 120), 50, nil)
+
+	tests := []struct {
+		p     image.Point
+		wheel bool
+		want  string
+		local image.Point
+	}{
+		{image.Pt(100, 150), false, "background", image.Pt(40, 150)},
+		{image.Pt(100, 50), false, "top", image.Pt(40, 50)},
+		{image.Pt(300, 50), false, "top", image.Pt(240, 50)},
+		{image.Pt(400, 250), false, "background", image.Pt(340, 250)},
+		{image.Pt(10, 10), false, "strip", image.Pt(10, 10)},
+		{image.Pt(470, 10), false, "", image.Point{}},
+		{image.Pt(120, 120), true, "knob", image.Pt(120, 120)},
+		{image.Pt(150, 150), true, "knob", image.Pt(150, 150)},
+		{image.Pt(160, 160), true, "", image.Point{}},
+		{image.Pt(75, 75), true, "", image.Point{}},
+	}
+	for _, tt := range tests {
+		r, local := d.regionAt(tt.p, tt.wheel)
+		name := ""
+		if r != nil {
+			name = r.Name
+		}
+		if name != tt.want || (r != nil && local != tt.local) {
+			t.Errorf("regionAt(%v, %v) = %q at %v, want %q at %v", tt.p, tt.wheel, name, local, tt.want, tt.local)
+		}
+	}
+
+	main.RemoveTouchRegion("top")
+	if r, _ := d.regionAt(image.Pt(100, 50), false); r == nil || r.Name != "background" {
+		t.Errorf("after removing top, got %v, want background", r)
+	}
+	if n := len(main.TouchRegions()); n != 3 {
+		t.Errorf("main has %d regions, want 3", n)
+	}
+}
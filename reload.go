@@ -0,0 +1,374 @@
+package loupedeck
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// DefaultReloadInterval is how often a ProfileWatcher checks its file
+// for changes, unless another interval is given.
+const DefaultReloadInterval = time.Second
+
+// profileErrorTime is how long a reload error is shown on the
+// display before the page is drawn again.
+const profileErrorTime = 5 * time.Second
+
+// ReloadFunc is a function signature used for callbacks when a
+// ProfileWatcher reloads its file.  err is nil if the new profile was
+// applied, and otherwise says why the previous one was kept.
+type ReloadFunc func(err error)
+
+// ProfileWatcher keeps a Profile up to date with a profile file.  When
+// the file changes, it's loaded and validated again, and only the
+// keys, buttons, and knobs whose configuration changed are rebound
+// and redrawn.  If the new file is invalid, then the previous profile
+// stays active, and the error is logged and shown on the display.
+type ProfileWatcher struct {
+	device *Device
+	path   string
+
+	mutex      sync.Mutex
+	applied    *appliedProfile
+	modTime    time.Time
+	size       int64
+	errorTimer *time.Timer
+	reloads    handlerList[struct{}, ReloadFunc]
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchProfileFile loads a profile file, applies it to the device,
+// and checks the file for changes every interval (or every
+// DefaultReloadInterval, if interval is zero).  Errors in the
+// initial file are returned, as with LoadProfileFile and Apply.
+func WatchProfileFile(d *Device, path string, interval time.Duration) (*ProfileWatcher, error) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+	w := &ProfileWatcher{
+		device: d,
+		path:   path,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	w.stat()
+
+	c, err := LoadProfileFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w.applied, err = c.apply(d)
+	if err != nil {
+		return nil, err
+	}
+
+	go w.poll(interval)
+	return w, nil
+}
+
+// Profile returns the Profile being kept up to date.  It stays the
+// same across reloads.
+func (w *ProfileWatcher) Profile() *Profile {
+	// applied is set once, before polling starts, and then only
+	// updated in place.
+	return w.applied.profile
+}
+
+// OnReload adds a callback for reloads, whether or not they succeed.
+func (w *ProfileWatcher) OnReload(f ReloadFunc) *Binding {
+	return w.reloads.addAny(f)
+}
+
+// Reload loads the file again now, without waiting for it to change.
+func (w *ProfileWatcher) Reload() error {
+	w.mutex.Lock()
+	err := w.reload()
+	w.mutex.Unlock()
+
+	for _, f := range w.reloads.get(struct{}{}) {
+		f := f
+		w.device.safely(func() { f(err) })
+	}
+	return err
+}
+
+// Close stops watching the file.  The profile stays active.
+func (w *ProfileWatcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+		if w.errorTimer != nil {
+			w.errorTimer.Stop()
+		}
+	})
+}
+
+// poll checks the file for changes until Close is called.
+func (w *ProfileWatcher) poll(interval time.Duration) {
+	defer close(w.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if w.changed() {
+				w.Reload()
+			}
+		}
+	}
+}
+
+// changed returns true if the file's modification time or size has
+// changed since it was last loaded.  A missing file, such as while an
+// editor is replacing it, doesn't count as a change.
+func (w *ProfileWatcher) changed() bool {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return false
+	}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return !info.ModTime().Equal(w.modTime) || info.Size() != w.size
+}
+
+// stat records the file's modification time and size.  The caller
+// must hold the mutex.
+func (w *ProfileWatcher) stat() {
+	if info, err := os.Stat(w.path); err == nil {
+		w.modTime, w.size = info.ModTime(), info.Size()
+	}
+}
+
+// reload loads, validates, and applies the file.  The caller must
+// hold the mutex.
+func (w *ProfileWatcher) reload() error {
+	w.stat()
+	c, err := LoadProfileFile(w.path)
+	if err == nil {
+		err = c.Validate(w.device)
+	}
+	if err != nil {
+		slog.Warn("Profile not reloaded, keeping the previous one", "file", w.path, "err", err)
+		w.showError(err)
+		return err
+	}
+
+	slog.Info("Reloading profile", "file", w.path)
+	w.applied.update(c)
+	if w.errorTimer != nil && w.errorTimer.Stop() {
+		w.applied.profile.redraw()
+	}
+	return nil
+}
+
+// showError draws a reload error across the main display, and draws
+// the current page again after profileErrorTime.  The caller must
+// hold the mutex.
+func (w *ProfileWatcher) showError(err error) {
+	disp := w.device.GetDisplay("main")
+	if disp == nil {
+		return
+	}
+
+	title, msg := "Profile not reloaded", err.Error()
+	var errs ProfileErrors
+	if errors.As(err, &errs) && len(errs) > 0 {
+		title = fmt.Sprintf("%s line %d", errs[0].File, errs[0].Line)
+		msg = errs[0].Msg
+	}
+
+	width, height := disp.Width(), disp.Height()
+	fg, bg := color.White, color.RGBA{128, 0, 0, 255}
+	im := solidImage(image.Pt(width, height), bg)
+	for i, s := range []string{title, msg} {
+		line, err := w.device.TextInBox(width, height/4, s, fg, bg)
+		if err != nil {
+			slog.Warn("Unable to draw profile error", "err", err)
+			return
+		}
+		r := image.Rect(0, height/4*(i+1), width, height/4*(i+2))
+		draw.Draw(im, r, line, image.Point{}, draw.Src)
+	}
+	disp.Draw(im, 0, 0)
+
+	if w.errorTimer != nil {
+		w.errorTimer.Stop()
+	}
+	profile := w.applied.profile
+	w.errorTimer = time.AfterFunc(profileErrorTime, profile.redraw)
+}
+
+// update changes the applied profile to match c, which must already
+// be valid.  Only the controls whose configuration changed are
+// rebound, and only their keys are redrawn.
+func (a *appliedProfile) update(c *ProfileConfig) {
+	old := a.config
+	a.config = c
+	p := a.profile
+	changed := 0
+
+	// Values are updated in place, so that the bindings using
+	// them keep working, and keep their current value where it's
+	// still in range.  Gauges showing a value whose range or format
+	// changed are replaced.
+	stale := map[string]bool{}
+	for name, vc := range c.Values {
+		ov := old.Values[name]
+		switch {
+		case ov == nil:
+			p.SetValue(name, vc.watched())
+		case !vc.sameRange(ov):
+			vc.update(p.Value(name))
+			stale[name] = true
+		case ov.format() != vc.format():
+			stale[name] = true
+		}
+	}
+	for name := range old.Values {
+		if c.Values[name] == nil {
+			p.mutex.Lock()
+			delete(p.values, name)
+			p.mutex.Unlock()
+		}
+	}
+
+	oldPages := map[string]*pageConfig{}
+	for _, pc := range old.Pages {
+		oldPages[pc.Name] = pc
+	}
+	names := make([]string, len(c.Pages))
+	for i, pc := range c.Pages {
+		names[i] = pc.Name
+		if ap := a.pages[pc.Name]; ap != nil {
+			changed += a.updatePage(ap, oldPages[pc.Name], pc, stale)
+		} else {
+			a.applyPage(pc)
+			changed++
+		}
+		delete(oldPages, pc.Name)
+	}
+	p.orderPages(names)
+
+	// Pages are removed last, so that if the current page is
+	// removed, the new first page is shown instead.
+	for name := range oldPages {
+		ap := a.pages[name]
+		delete(a.pages, name)
+		p.RemovePage(name)
+		a.clearPage(ap)
+		changed++
+	}
+	if p.Current() == nil {
+		p.Show(names[0])
+	}
+	slog.Info("Profile updated", "changes", changed)
+}
+
+// updatePage rebinds the controls on a page whose configuration
+// changed from old to pc, and returns how many there were.  Gauges
+// showing stale values are replaced too.
+func (a *appliedProfile) updatePage(ap *appliedPage, old, pc *pageConfig, stale map[string]bool) int {
+	changed := 0
+	for name, kc := range pc.Keys {
+		if ok := old.Keys[name]; ok == nil || !kc.same(ok) || (kc.Gauge != "" && stale[kc.Gauge]) {
+			ap.keys[name] = a.applyKey(ap, name, kc)
+			changed++
+		}
+	}
+	for name := range old.Keys {
+		if pc.Keys[name] == nil {
+			a.applyKey(ap, name, nil)
+			delete(ap.keys, name)
+			changed++
+		}
+	}
+
+	for name, bc := range pc.Buttons {
+		if ob := old.Buttons[name]; ob == nil || !bc.same(ob) {
+			ap.buttons[name].Unbind()
+			ap.buttons[name] = a.applyButton(ap, name, bc)
+			changed++
+		}
+	}
+	for name := range old.Buttons {
+		if pc.Buttons[name] == nil {
+			ap.buttons[name].Unbind()
+			delete(ap.buttons, name)
+			changed++
+		}
+	}
+
+	for name, kc := range pc.Knobs {
+		if ok := old.Knobs[name]; ok == nil || !kc.same(ok) {
+			ap.knobs[name].Unbind()
+			ap.knobs[name] = a.applyKnob(ap, name, kc)
+			changed++
+		}
+	}
+	for name := range old.Knobs {
+		if pc.Knobs[name] == nil {
+			ap.knobs[name].Unbind()
+			delete(ap.knobs, name)
+			changed++
+		}
+	}
+	return changed
+}
+
+// sameRange returns true if two values have the same bounds, step,
+// default, and mode.
+func (v *valueConfig) sameRange(o *valueConfig) bool {
+	return v.Min == o.Min && v.Max == o.Max && v.step() == o.step() &&
+		v.Default == o.Default && v.Wrap == o.Wrap
+}
+
+// update changes an existing Watched value to match the config.
+func (v *valueConfig) update(w *Watched[float64]) {
+	w.SetRange(v.Min, v.Max)
+	w.SetStep(v.step())
+	w.SetDefault(v.Default)
+	mode := Clamp
+	if v.Wrap {
+		mode = Wrap
+	}
+	w.SetMode(mode)
+}
+
+// The same methods compare configs, ignoring line numbers.
+
+func (k *keyConfig) same(o *keyConfig) bool {
+	return k.Label == o.Label && k.Icon == o.Icon && k.Color == o.Color &&
+		k.Background == o.Background && k.Gauge == o.Gauge &&
+		k.Action.same(o.Action) && reflect.DeepEqual(k.icon, o.icon)
+}
+
+func (b *buttonConfig) same(o *buttonConfig) bool {
+	return b.LED == o.LED && b.Action.same(o.Action)
+}
+
+func (k *knobConfig) same(o *knobConfig) bool {
+	return k.Value == o.Value && k.Action.same(o.Action)
+}
+
+func (a *actionConfig) same(o *actionConfig) bool {
+	if a == nil || o == nil {
+		return a == o
+	}
+	return a.Type == o.Type && reflect.DeepEqual(a.action, o.action)
+}
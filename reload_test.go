@@ -0,0 +1,238 @@
+package loupedeck
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+const reloadBase = `
+values:
+  volume: {min: 0, max: 100, default: 50}
+pages:
+  - name: home
+    keys:
+      Touch1: {label: Same}
+      Touch2: {label: Before}
+      Touch3: {label: Removed}
+      Touch4: {label: Volume, gauge: volume}
+    buttons:
+      Button1: {led: "#00ff00", action: {type: show, page: away}}
+    knobs:
+      Knob1: {value: volume}
+  - name: away
+    keys:
+      Touch1: {label: Away}
+`
+
+func TestProfileReload(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		// replaced and kept list the keys on the home page whose
+		// widgets should and shouldn't be rebuilt.
+		replaced, kept []TouchButton
+		removed        []TouchButton
+		pages          []string
+		err            bool
+	}{
+		{
+			name:  "unchanged",
+			yaml:  reloadBase,
+			kept:  []TouchButton{Touch1, Touch2, Touch3, Touch4},
+			pages: []string{"home", "away"},
+		},
+		{
+			name: "key changes",
+			yaml: `
+values:
+  volume: {min: 0, max: 100, default: 50}
+pages:
+  - name: home
+    keys:
+      Touch1: {label: Same}
+      Touch2: {label: After}
+      Touch4: {label: Volume, gauge: volume}
+      Touch5: {label: Added}
+    buttons:
+      Button1: {led: "#00ff00", action: {type: show, page: away}}
+    knobs:
+      Knob1: {value: volume}
+  - name: away
+    keys:
+      Touch1: {label: Away}
+`,
+			replaced: []TouchButton{Touch2, Touch5},
+			kept:     []TouchButton{Touch1, Touch4},
+			removed:  []TouchButton{Touch3},
+			pages:    []string{"home", "away"},
+		},
+		{
+			name: "value range changes",
+			yaml: `
+values:
+  volume: {min: 0, max: 11, default: 5}
+pages:
+  - name: home
+    keys:
+      Touch1: {label: Same}
+      Touch2: {label: Before}
+      Touch3: {label: Removed}
+      Touch4: {label: Volume, gauge: volume}
+    buttons:
+      Button1: {led: "#00ff00", action: {type: show, page: away}}
+    knobs:
+      Knob1: {value: volume}
+  - name: away
+    keys:
+      Touch1: {label: Away}
+`,
+			replaced: []TouchButton{Touch4},
+			kept:     []TouchButton{Touch1, Touch2, Touch3},
+			pages:    []string{"home", "away"},
+		},
+		{
+			name: "page removed and added",
+			yaml: `
+values:
+  volume: {min: 0, max: 100, default: 50}
+pages:
+  - name: home
+    keys:
+      Touch1: {label: Same}
+      Touch2: {label: Before}
+      Touch3: {label: Removed}
+      Touch4: {label: Volume, gauge: volume}
+    buttons:
+      Button1: {led: "#00ff00", action: {type: show, page: new}}
+    knobs:
+      Knob1: {value: volume}
+  - name: new
+`,
+			kept:  []TouchButton{Touch1, Touch2, Touch3, Touch4},
+			pages: []string{"home", "new"},
+		},
+		{
+			name: "invalid",
+			yaml: `
+pages:
+  - name: home
+    keys:
+      Touch1: {label: Same, color: blurple}
+`,
+			kept:  []TouchButton{Touch1, Touch2, Touch3, Touch4},
+			pages: []string{"home", "away"},
+			err:   true,
+		},
+	}
+	for _, tt := range tests {
+		d := newTestDevice(t, "0004")
+		path := filepath.Join(t.TempDir(), "profile.yaml")
+		if err := os.WriteFile(path, []byte(reloadBase), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		w, err := WatchProfileFile(d, path, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		home := w.Profile().Current()
+		before := map[TouchButton]Widget{}
+		for _, b := range d.TouchButtons() {
+			before[b] = home.Widget(b)
+		}
+		volume := w.Profile().Value("volume")
+
+		if err := os.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		err = w.Reload()
+		var errs ProfileErrors
+		if tt.err != errors.As(err, &errs) {
+			t.Errorf("%s: Reload() = %v", tt.name, err)
+		}
+
+		if p := w.Profile().Current(); p != home {
+			t.Errorf("%s: current page changed to %q", tt.name, p.Name)
+		}
+		for _, b := range tt.kept {
+			if home.Widget(b) != before[b] {
+				t.Errorf("%s: %v was rebuilt", tt.name, b)
+			}
+		}
+		for _, b := range tt.replaced {
+			if w := home.Widget(b); w == nil || w == before[b] {
+				t.Errorf("%s: %v wasn't rebuilt", tt.name, b)
+			}
+		}
+		for _, b := range tt.removed {
+			if w := home.Widget(b); w != nil {
+				t.Errorf("%s: %v still has a widget", tt.name, b)
+			}
+		}
+		if v := w.Profile().Value("volume"); v != volume {
+			t.Errorf("%s: volume was replaced rather than updated", tt.name)
+		}
+		p := w.Profile()
+		p.mutex.Lock()
+		var pages []string
+		for _, pg := range p.pages {
+			pages = append(pages, pg.Name)
+		}
+		p.mutex.Unlock()
+		if !slices.Equal(pages, tt.pages) {
+			t.Errorf("%s: pages are %v, want %v", tt.name, pages, tt.pages)
+		}
+		w.Close()
+		p.Close()
+	}
+}
+
+func TestProfileReloadRemovesPageBindings(t *testing.T) {
+	const before = `
+values:
+  volume: {min: 0, max: 100, default: 50}
+pages:
+  - name: home
+  - name: away
+    keys:
+      Touch1: {label: Away, action: {type: back}}
+    buttons:
+      Button1: {led: "#00ff00", action: {type: show, page: home}}
+    knobs:
+      Knob1: {value: volume}
+`
+	const after = `
+pages:
+  - name: home
+`
+	d := newTestDevice(t, "0004")
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	if err := os.WriteFile(path, []byte(before), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := WatchProfileFile(d, path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Profile().Close()
+	defer w.Close()
+	away := w.Profile().Page("away")
+
+	if err := os.WriteFile(path, []byte(after), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	away.mutex.Lock()
+	defer away.mutex.Unlock()
+	if n := len(away.entries); n != 0 {
+		t.Errorf("removed page still has %d bindings", n)
+	}
+	if n := len(away.widgets); n != 0 {
+		t.Errorf("removed page still has %d widgets", n)
+	}
+}
@@ -0,0 +1,285 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// The helpers in this file are for drawing on round displays, like
+// the Loupedeck CT's "dial".  Angles are in degrees, measured
+// clockwise from the top of the circle, the same as
+// WheelTouchEvent.Angle.
+
+// PolarToPoint returns the point at an angle and distance from
+// center.
+func PolarToPoint(center image.Point, angle, radius float64) image.Point {
+	x, y := polarToXY(float64(center.X), float64(center.Y), angle, radius)
+	return image.Pt(int(math.Round(x)), int(math.Round(y)))
+}
+
+// PointToPolar returns the angle and distance of p from center.  The
+// angle is from 0 up to (but not including) 360.
+func PointToPolar(center, p image.Point) (angle, radius float64) {
+	return xyToPolar(float64(p.X-center.X), float64(p.Y-center.Y))
+}
+
+func polarToXY(cx, cy, angle, radius float64) (float64, float64) {
+	a := angle * math.Pi / 180
+	return cx + radius*math.Sin(a), cy - radius*math.Cos(a)
+}
+
+func xyToPolar(dx, dy float64) (angle, radius float64) {
+	angle = math.Atan2(dx, -dy) * 180 / math.Pi
+	if angle < 0 {
+		angle += 360
+	}
+	return angle, math.Hypot(dx, dy)
+}
+
+// CircleMask returns an anti-aliased mask of the largest circle that
+// fits inside r.
+func CircleMask(r image.Rectangle) *image.Alpha {
+	radius := float64(min(r.Dx(), r.Dy())) / 2
+	center := rectCenter(r)
+	mask := image.NewAlpha(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			dist := math.Hypot(float64(x)+0.5-center[0], float64(y)+0.5-center[1])
+			// Full coverage inside, with a one-pixel soft
+			// edge.
+			cover := clamp01(radius - dist + 0.5)
+			mask.SetAlpha(x, y, color.Alpha{uint8(math.Round(cover * 255))})
+		}
+	}
+	return mask
+}
+
+// MaskCircle returns a copy of im with everything outside of the
+// largest circle that fits inside it replaced by bg.  Images drawn
+// on a round display should usually be masked, so that their corners
+// don't show around the edge.
+func MaskCircle(im image.Image, bg color.Color) *image.RGBA {
+	r := im.Bounds()
+	out := image.NewRGBA(r)
+	draw.Draw(out, r, &image.Uniform{bg}, image.Point{}, draw.Src)
+	draw.DrawMask(out, r, im, r.Min, CircleMask(r), r.Min, draw.Over)
+	return out
+}
+
+// DrawArc draws an anti-aliased arc of the given thickness, centered
+// on a circle with the given radius.  The arc starts at the start
+// angle and runs clockwise for sweep degrees; a sweep of 360 or more
+// draws a full ring.
+func DrawArc(dst draw.Image, center image.Point, radius, thickness, start, sweep float64, c color.Color) {
+	if sweep <= 0 || thickness <= 0 {
+		return
+	}
+	outer := radius + thickness/2 + 1
+	box := image.Rect(
+		center.X-int(math.Ceil(outer)), center.Y-int(math.Ceil(outer)),
+		center.X+int(math.Ceil(outer)), center.Y+int(math.Ceil(outer)),
+	).Intersect(dst.Bounds())
+	if box.Empty() {
+		return
+	}
+	cx, cy := float64(center.X), float64(center.Y)
+	mask := arcMask(box, [2]float64{cx, cy}, radius, thickness, start, sweep)
+	draw.DrawMask(dst, box, &image.Uniform{c}, image.Point{}, mask, box.Min, draw.Over)
+}
+
+// DrawRing draws a ring progress indicator: a full ring in bg, with
+// the fraction of it (from 0 to 1) starting at the top filled in
+// with fg.
+func DrawRing(dst draw.Image, center image.Point, radius, thickness, fraction float64, fg, bg color.Color) {
+	DrawArc(dst, center, radius, thickness, 0, 360, bg)
+	DrawArc(dst, center, radius, thickness, 0, clamp01(fraction)*360, fg)
+}
+
+// DrawGauge draws a gauge, like a car's speedometer: a track in bg
+// starting at the start angle and running clockwise for sweep
+// degrees, filled in with fg up to value (from 0 to 1), and with a
+// needle in fg pointing at the value.  A start of 225 and a sweep of
+// 270 gives the usual gauge with a gap at the bottom.
+func DrawGauge(dst draw.Image, center image.Point, radius, thickness, start, sweep, value float64, fg, bg color.Color) {
+	value = clamp01(value)
+	DrawArc(dst, center, radius, thickness, start, sweep, bg)
+	DrawArc(dst, center, radius, thickness, start, value*sweep, fg)
+
+	angle := start + value*sweep
+	cx, cy := float64(center.X), float64(center.Y)
+	x, y := polarToXY(cx, cy, angle, radius-thickness)
+	drawLine(dst, cx, cy, x, y, 3, fg)
+}
+
+// drawLine draws an anti-aliased line with round ends.
+func drawLine(dst draw.Image, x0, y0, x1, y1, width float64, c color.Color) {
+	pad := width/2 + 1
+	box := image.Rect(
+		int(math.Floor(math.Min(x0, x1)-pad)), int(math.Floor(math.Min(y0, y1)-pad)),
+		int(math.Ceil(math.Max(x0, x1)+pad)), int(math.Ceil(math.Max(y0, y1)+pad)),
+	).Intersect(dst.Bounds())
+	if box.Empty() {
+		return
+	}
+
+	dx, dy := x1-x0, y1-y0
+	length2 := dx*dx + dy*dy
+	mask := image.NewAlpha(box)
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			px, py := float64(x)+0.5-x0, float64(y)+0.5-y0
+			// Find the distance to the nearest point on the
+			// segment.
+			t := 0.0
+			if length2 > 0 {
+				t = clamp01((px*dx + py*dy) / length2)
+			}
+			dist := math.Hypot(px-t*dx, py-t*dy)
+			cover := clamp01(width/2 - dist + 0.5)
+			mask.SetAlpha(x, y, color.Alpha{uint8(math.Round(cover * 255))})
+		}
+	}
+	draw.DrawMask(dst, box, &image.Uniform{c}, image.Point{}, mask, box.Min, draw.Over)
+}
+
+// DrawTextOnCircle draws text bent around a circle, centered on
+// angle, with the bottom of the letters (the baseline) on a circle
+// of the given radius.  The letters face outwards and read
+// clockwise, so text near the top of the circle reads normally.
+func DrawTextOnCircle(dst draw.Image, face font.Face, s string, center image.Point, radius, angle float64, c color.Color) {
+	if radius <= 0 {
+		return
+	}
+
+	var total fixed.Int26_6
+	for _, r := range s {
+		adv, _ := face.GlyphAdvance(r)
+		total += adv
+	}
+	metrics := face.Metrics()
+	ascent := metrics.Ascent.Ceil()
+	height := ascent + metrics.Descent.Ceil()
+
+	// Convert advances to angles, measured at the baseline.
+	degrees := func(x fixed.Int26_6) float64 {
+		return float64(x) / 64 / radius * 180 / math.Pi
+	}
+	a := angle - degrees(total)/2
+	cx, cy := float64(center.X), float64(center.Y)
+
+	for _, r := range s {
+		adv, _ := face.GlyphAdvance(r)
+		width := adv.Ceil()
+		mid := a + degrees(adv)/2
+		a += degrees(adv)
+		if width == 0 {
+			continue
+		}
+
+		// Draw the glyph upright, then rotate it into place
+		// with the middle of its baseline on the circle.
+		glyph := image.NewAlpha(image.Rect(0, 0, width, height))
+		fd := font.Drawer{Dst: glyph, Src: image.Opaque, Face: face, Dot: fixed.P(0, ascent)}
+		fd.DrawString(string(r))
+
+		ax, ay := polarToXY(cx, cy, mid, radius)
+		drawRotated(dst, glyph, image.Pt(width/2, ascent), ax, ay, mid, c)
+	}
+}
+
+// drawRotated draws the mask src in color c, rotated clockwise by
+// angle degrees around the point anchor in src, with anchor placed
+// at (x, y) in dst.
+func drawRotated(dst draw.Image, src *image.Alpha, anchor image.Point, x, y, angle float64, c color.Color) {
+	a := angle * math.Pi / 180
+	sin, cos := math.Sin(a), math.Cos(a)
+
+	// The rotated image fits within a circle around the anchor
+	// that reaches the farthest corner of src.
+	sb := src.Bounds()
+	reach := 0.0
+	for _, p := range []image.Point{sb.Min, {sb.Max.X, sb.Min.Y}, {sb.Min.X, sb.Max.Y}, sb.Max} {
+		reach = math.Max(reach, math.Hypot(float64(p.X-anchor.X), float64(p.Y-anchor.Y)))
+	}
+	box := image.Rect(
+		int(math.Floor(x-reach)), int(math.Floor(y-reach)),
+		int(math.Ceil(x+reach)), int(math.Ceil(y+reach)),
+	).Intersect(dst.Bounds())
+	if box.Empty() {
+		return
+	}
+
+	mask := image.NewAlpha(box)
+	for py := box.Min.Y; py < box.Max.Y; py++ {
+		for px := box.Min.X; px < box.Max.X; px++ {
+			// Rotate the pixel center back into src.
+			dx, dy := float64(px)+0.5-x, float64(py)+0.5-y
+			sx := dx*cos + dy*sin + float64(anchor.X)
+			sy := -dx*sin + dy*cos + float64(anchor.Y)
+			mask.SetAlpha(px, py, color.Alpha{bilinear(src, sx-0.5, sy-0.5)})
+		}
+	}
+	draw.DrawMask(dst, box, &image.Uniform{c}, image.Point{}, mask, box.Min, draw.Over)
+}
+
+// bilinear samples src at a fractional position, treating pixels
+// outside of src as transparent.
+func bilinear(src *image.Alpha, x, y float64) uint8 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	at := func(x, y int) float64 {
+		if !image.Pt(x, y).In(src.Rect) {
+			return 0
+		}
+		return float64(src.AlphaAt(x, y).A)
+	}
+	ix, iy := int(x0), int(y0)
+	v := (at(ix, iy)*(1-fx)+at(ix+1, iy)*fx)*(1-fy) +
+		(at(ix, iy+1)*(1-fx)+at(ix+1, iy+1)*fx)*fy
+	return uint8(math.Round(v))
+}
+
+// rectCenter returns the center of r.
+func rectCenter(r image.Rectangle) [2]float64 {
+	return [2]float64{float64(r.Min.X+r.Max.X) / 2, float64(r.Min.Y+r.Max.Y) / 2}
+}
+
+// arcMask returns an anti-aliased mask covering box, which is set
+// where an arc around center passes.  See DrawArc.
+func arcMask(box image.Rectangle, center [2]float64, radius, thickness, start, sweep float64) *image.Alpha {
+	mask := image.NewAlpha(box)
+	full := sweep >= 360
+	start = math.Mod(math.Mod(start, 360)+360, 360)
+
+	for y := box.Min.Y; y < box.Max.Y; y++ {
+		for x := box.Min.X; x < box.Max.X; x++ {
+			angle, r := xyToPolar(float64(x)+0.5-center[0], float64(y)+0.5-center[1])
+
+			// Coverage across the ring, with a one-pixel
+			// soft edge.
+			cover := clamp01(thickness/2 - math.Abs(r-radius) + 0.5)
+			if cover == 0 {
+				continue
+			}
+			if !full {
+				// Distance in pixels to the nearer
+				// end of the arc, negative outside it.
+				into := math.Mod(angle-start+360, 360)
+				var edge float64
+				if into <= sweep {
+					edge = math.Min(into, sweep-into)
+				} else {
+					edge = -math.Min(into-sweep, 360-into)
+				}
+				cover *= clamp01(edge*math.Pi/180*r + 0.5)
+			}
+			mask.SetAlpha(x, y, color.Alpha{uint8(math.Round(cover * 255))})
+		}
+	}
+	return mask
+}
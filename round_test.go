@@ -0,0 +1,93 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestPolar(t *testing.T) {
+	center := image.Pt(100, 100)
+	tests := []struct {
+		p      image.Point
+		angle  float64
+		radius float64
+	}{
+		{image.Pt(100, 50), 0, 50},
+		{image.Pt(150, 100), 90, 50},
+		{image.Pt(100, 150), 180, 50},
+		{image.Pt(50, 100), 270, 50},
+		{image.Pt(110, 90), 45, math.Sqrt2 * 10},
+	}
+	for _, tt := range tests {
+		angle, radius := PointToPolar(center, tt.p)
+		if math.Abs(angle-tt.angle) > 1e-9 || math.Abs(radius-tt.radius) > 1e-9 {
+			t.Errorf("PointToPolar(%v) = %v, %v, want %v, %v", tt.p, angle, radius, tt.angle, tt.radius)
+		}
+		if got := PolarToPoint(center, tt.angle, tt.radius); got != tt.p {
+			t.Errorf("PolarToPoint(%v, %v) = %v, want %v", tt.angle, tt.radius, got, tt.p)
+		}
+	}
+}
+
+func TestCircleMask(t *testing.T) {
+	tests := []struct {
+		r    image.Rectangle
+		p    image.Point
+		want uint8
+	}{
+		{image.Rect(0, 0, 100, 100), image.Pt(50, 50), 0xff},
+		{image.Rect(0, 0, 100, 100), image.Pt(50, 2), 0xff},
+		{image.Rect(0, 0, 100, 100), image.Pt(0, 0), 0},
+		{image.Rect(0, 0, 100, 100), image.Pt(99, 99), 0},
+		// Odd sizes have a pixel right in the middle.
+		{image.Rect(0, 0, 5, 5), image.Pt(2, 2), 0xff},
+		{image.Rect(0, 0, 7, 7), image.Pt(3, 3), 0xff},
+		{image.Rect(0, 0, 241, 241), image.Pt(120, 120), 0xff},
+		{image.Rect(0, 0, 241, 241), image.Pt(0, 0), 0},
+		{image.Rect(10, 10, 15, 15), image.Pt(12, 12), 0xff},
+	}
+	for _, tt := range tests {
+		mask := CircleMask(tt.r)
+		if got := mask.AlphaAt(tt.p.X, tt.p.Y).A; got != tt.want {
+			t.Errorf("CircleMask(%v) at %v = %#x, want %#x", tt.r, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestDrawArc(t *testing.T) {
+	center := image.Pt(50, 50)
+	red := color.RGBA{0xff, 0, 0, 0xff}
+	tests := []struct {
+		name         string
+		start, sweep float64
+		// painted and blank are angles on the arc's circle.
+		painted, blank []float64
+	}{
+		{"right half", 0, 180, []float64{10, 90, 170}, []float64{190, 270, 350}},
+		{"across the top", 270, 180, []float64{280, 0, 80}, []float64{100, 180, 260}},
+		{"full ring", 0, 360, []float64{0, 90, 180, 270}, nil},
+		{"empty", 0, 0, nil, []float64{0, 90, 180, 270}},
+	}
+	for _, tt := range tests {
+		im := image.NewRGBA(image.Rect(0, 0, 100, 100))
+		DrawArc(im, center, 40, 6, tt.start, tt.sweep, red)
+		for _, a := range tt.painted {
+			p := PolarToPoint(center, a, 40)
+			if got := im.RGBAAt(p.X, p.Y); got != red {
+				t.Errorf("%s: pixel at %v degrees is %v, want %v", tt.name, a, got, red)
+			}
+		}
+		for _, a := range tt.blank {
+			p := PolarToPoint(center, a, 40)
+			if got := im.RGBAAt(p.X, p.Y); got.A != 0 {
+				t.Errorf("%s: pixel at %v degrees is %v, want blank", tt.name, a, got)
+			}
+		}
+		// The center is never drawn on.
+		if got := im.RGBAAt(50, 50); got.A != 0 {
+			t.Errorf("%s: center is %v, want blank", tt.name, got)
+		}
+	}
+}
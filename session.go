@@ -0,0 +1,186 @@
+package loupedeck
+
+import (
+	"image"
+	"log/slog"
+	"time"
+)
+
+// TouchPhase describes where a TouchSession is in its lifetime.
+type TouchPhase uint8
+
+const (
+	// TouchPhaseBegin is sent when a finger first touches the screen.
+	TouchPhaseBegin TouchPhase = 1
+	// TouchPhaseMove is sent each time the device reports a new
+	// position for a finger that is still down.
+	TouchPhaseMove TouchPhase = 2
+	// TouchPhaseEnd is sent when the finger is lifted.
+	TouchPhaseEnd TouchPhase = 3
+)
+
+// TouchSession tracks a single finger on the touchscreen, from the
+// moment it touches the screen until it is lifted.  The Loupedeck
+// repeats touch messages while a finger moves, tagging each with a
+// per-finger ID, which lets several fingers be tracked at once.
+//
+// TouchSessions are passed to callbacks by value, so they're safe to
+// keep after the callback returns.
+type TouchSession struct {
+	// ID is the device's identifier for this finger.  IDs are
+	// reused once a finger is lifted.
+	ID byte
+	// Button is the TouchButton where the touch started.
+	Button TouchButton
+	// Start is where the touch started, in touchscreen
+	// coordinates as seen in the device's current orientation.
+	Start image.Point
+	// Position is the most recently reported position.
+	Position image.Point
+	// StartTime is when the touch started.
+	StartTime time.Time
+	// LastTime is when Position was last updated.
+	LastTime time.Time
+	// VelocityX and VelocityY are the smoothed speed of the
+	// finger, in pixels per second.
+	VelocityX, VelocityY float64
+
+	region *TouchRegion
+}
+
+// Duration returns how long the finger has been down.
+func (s TouchSession) Duration() time.Duration {
+	return s.LastTime.Sub(s.StartTime)
+}
+
+// Delta returns how far the finger has moved since the touch started.
+func (s TouchSession) Delta() image.Point {
+	return s.Position.Sub(s.Start)
+}
+
+// TouchSessionFunc is a function signature used for callbacks on
+// TouchSession events.
+type TouchSessionFunc func(TouchPhase, TouchSession)
+
+// TouchMoveFunc is a function signature used for callbacks when a
+// finger moves on the touchscreen.  The TouchButton is the button
+// where the touch started.
+type TouchMoveFunc func(TouchButton, TouchSession)
+
+// BindTouchSession adds a callback for every phase of every touch
+// on the touchscreen.
+func (d *Device) BindTouchSession(f TouchSessionFunc) *Binding {
+	return d.bindings.touchSession.addAny(f)
+}
+
+// BindTouchMove adds a callback for finger movement on a specific
+// TouchButton.  The callback is called with every new position
+// reported for a touch that started on the TouchButton, even once the
+// finger has moved off of it.
+func (d *Device) BindTouchMove(b TouchButton, f TouchMoveFunc) *Binding {
+	return d.bindings.touchMove.add(b, f)
+}
+
+// BindAnyTouchMove adds a callback for finger movement on any
+// TouchButton.
+func (d *Device) BindAnyTouchMove(f TouchMoveFunc) *Binding {
+	return d.bindings.touchMove.addAny(f)
+}
+
+// touchKey identifies a finger.  The wheel and the main touchscreen
+// number their fingers separately, so the same ID can be in use on
+// both at once.
+type touchKey struct {
+	wheel bool
+	id    byte
+}
+
+// touchKeyFor returns the touchKey for a finger touching b.
+func touchKeyFor(id byte, b TouchButton) touchKey {
+	return touchKey{wheel: b == TouchWheel, id: id}
+}
+
+// trackTouch records a touch message for the finger with the given
+// ID, starting a new session if the finger wasn't already down.
+func (d *Device) trackTouch(id byte, b TouchButton, p image.Point, now time.Time) (*TouchSession, TouchPhase) {
+	d.touchMutex.Lock()
+	defer d.touchMutex.Unlock()
+	return d.trackTouchLocked(id, b, p, now)
+}
+
+// trackTouchLocked is trackTouch for callers that already hold
+// touchMutex.
+func (d *Device) trackTouchLocked(id byte, b TouchButton, p image.Point, now time.Time) (*TouchSession, TouchPhase) {
+	key := touchKeyFor(id, b)
+	s := d.touches[key]
+	if s == nil {
+		s = &TouchSession{
+			ID:        id,
+			Button:    b,
+			Start:     p,
+			Position:  p,
+			StartTime: now,
+			LastTime:  now,
+		}
+		d.touches[key] = s
+		return s, TouchPhaseBegin
+	}
+
+	s.move(p, now)
+	return s, TouchPhaseMove
+}
+
+// endTouch finishes the session for the finger with the given ID.
+// If the start of the touch was never seen, then a zero-length
+// session is created for it.
+func (d *Device) endTouch(id byte, b TouchButton, p image.Point, now time.Time) *TouchSession {
+	d.touchMutex.Lock()
+	defer d.touchMutex.Unlock()
+
+	key := touchKeyFor(id, b)
+	s := d.touches[key]
+	if s == nil {
+		slog.Info("Touch end without matching start", "id", id)
+		s, _ = d.trackTouchLocked(id, b, p, now)
+	} else {
+		s.move(p, now)
+	}
+	delete(d.touches, key)
+	return s
+}
+
+// move updates the session's position and velocity.
+func (s *TouchSession) move(p image.Point, now time.Time) {
+	dt := now.Sub(s.LastTime).Seconds()
+	if dt > 0 {
+		// Touch reports are noisy, so smooth the velocity
+		// with an exponential moving average.
+		const smoothing = 0.5
+		vx := float64(p.X-s.Position.X) / dt
+		vy := float64(p.Y-s.Position.Y) / dt
+		s.VelocityX = smoothing*s.VelocityX + (1-smoothing)*vx
+		s.VelocityY = smoothing*s.VelocityY + (1-smoothing)*vy
+	}
+	s.Position = p
+	s.LastTime = now
+}
+
+// dispatchTouchSession calls the session and move callbacks for a
+// touch.
+func (d *Device) dispatchTouchSession(phase TouchPhase, s *TouchSession) {
+	session := *s
+	key := controlKey{sessionControl, uint32(s.ID)}
+	if s.Button == TouchWheel {
+		key.id |= 1 << 8
+	}
+	for _, f := range d.bindings.touchSession.get(struct{}{}) {
+		f := f
+		d.run(key, func() { f(phase, session) })
+	}
+	if phase == TouchPhaseMove {
+		for _, f := range d.bindings.touchMove.get(s.Button) {
+			f := f
+			d.run(controlKey{touchControl, uint32(s.Button)}, func() { f(session.Button, session) })
+		}
+	}
+}
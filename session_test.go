@@ -0,0 +1,67 @@
+package loupedeck
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestTrackTouch(t *testing.T) {
+	d := &Device{touches: map[touchKey]*TouchSession{}}
+	start := time.Now()
+
+	tests := []struct {
+		name  string
+		id    byte
+		b     TouchButton
+		end   bool
+		phase TouchPhase
+	}{
+		{"first finger", 1, Touch1, false, TouchPhaseBegin},
+		{"first finger moves", 1, Touch1, false, TouchPhaseMove},
+		{"wheel with the same ID", 1, TouchWheel, false, TouchPhaseBegin},
+		{"wheel moves", 1, TouchWheel, false, TouchPhaseMove},
+		{"second finger", 2, Touch2, false, TouchPhaseBegin},
+		{"wheel lifted", 1, TouchWheel, true, TouchPhaseEnd},
+		{"first finger still down", 1, Touch1, false, TouchPhaseMove},
+		{"wheel touched again", 1, TouchWheel, false, TouchPhaseBegin},
+	}
+	for i, tt := range tests {
+		now := start.Add(time.Duration(i) * 10 * time.Millisecond)
+		p := image.Pt(i, i)
+		if tt.end {
+			s := d.endTouch(tt.id, tt.b, p, now)
+			if s.Button != tt.b {
+				t.Errorf("%s: ended session on %v, want %v", tt.name, s.Button, tt.b)
+			}
+			continue
+		}
+		s, phase := d.trackTouch(tt.id, tt.b, p, now)
+		if phase != tt.phase || s.Button != tt.b {
+			t.Errorf("%s: got phase %v on %v, want %v on %v", tt.name, phase, s.Button, tt.phase, tt.b)
+		}
+	}
+
+	touches := d.ActiveTouches()
+	want := []TouchButton{Touch1, TouchWheel, Touch2}
+	if len(touches) != len(want) {
+		t.Fatalf("ActiveTouches() returned %d sessions, want %d", len(touches), len(want))
+	}
+	for i, s := range touches {
+		if s.Button != want[i] {
+			t.Errorf("ActiveTouches()[%d] is on %v, want %v", i, s.Button, want[i])
+		}
+	}
+}
+
+func TestEndTouchWithoutStart(t *testing.T) {
+	d := &Device{touches: map[touchKey]*TouchSession{}}
+	now := time.Now()
+	s := d.endTouch(3, Touch5, image.Pt(10, 20), now)
+	if s.ID != 3 || s.Button != Touch5 || s.Duration() != 0 {
+		t.Errorf("endTouch() = %+v, want a zero-length session for finger 3 on Touch5", s)
+	}
+	if n := len(d.ActiveTouches()); n != 0 {
+		t.Errorf("%d touches still active", n)
+	}
+}
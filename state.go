@@ -0,0 +1,144 @@
+package loupedeck
+
+import (
+	"sort"
+)
+
+// InputState is a snapshot of the state of a Device's controls at a
+// single moment.  It's a copy, so it's safe to keep and read from
+// any goroutine.
+type InputState struct {
+	// Pressed lists the buttons that are held down, in order.
+	Pressed []Button
+	// Touches lists the fingers currently on the touchscreen,
+	// ordered by ID.
+	Touches []TouchSession
+	// Knobs holds the position of each knob that has been turned,
+	// as counted by KnobPosition.
+	Knobs map[Knob]int
+}
+
+// IsPressed returns true if the button is held down on the device.
+// This is the physical state of the button, regardless of any
+// chords, modifiers, or long-presses that it's part of.
+func (s InputState) IsPressed(b Button) bool {
+	i := sort.Search(len(s.Pressed), func(i int) bool { return s.Pressed[i] >= b })
+	return i < len(s.Pressed) && s.Pressed[i] == b
+}
+
+// IsPressed returns true if the button is currently held down on the
+// device.  This is the physical state of the button, regardless of
+// any chords, modifiers, or long-presses that it's part of.
+func (d *Device) IsPressed(b Button) bool {
+	d.pressedMutex.RLock()
+	defer d.pressedMutex.RUnlock()
+	return d.pressed[b]
+}
+
+// PressedButtons returns the buttons that are currently held down,
+// in order.
+func (d *Device) PressedButtons() []Button {
+	d.pressedMutex.RLock()
+	defer d.pressedMutex.RUnlock()
+	return d.pressedButtons()
+}
+
+// pressedButtons is PressedButtons for callers that hold
+// pressedMutex.
+func (d *Device) pressedButtons() []Button {
+	buttons := make([]Button, 0, len(d.pressed))
+	for b := range d.pressed {
+		buttons = append(buttons, b)
+	}
+	sort.Slice(buttons, func(i, j int) bool { return buttons[i] < buttons[j] })
+	return buttons
+}
+
+// ActiveTouches returns a copy of the TouchSession for each finger
+// that is currently on the touchscreen or the wheel, ordered by ID,
+// with main touchscreen fingers before wheel fingers that share an
+// ID.
+func (d *Device) ActiveTouches() []TouchSession {
+	d.touchMutex.RLock()
+	defer d.touchMutex.RUnlock()
+	return d.activeTouches()
+}
+
+// activeTouches is ActiveTouches for callers that hold touchMutex.
+func (d *Device) activeTouches() []TouchSession {
+	touches := make([]TouchSession, 0, len(d.touches))
+	for _, s := range d.touches {
+		touches = append(touches, *s)
+	}
+	sort.Slice(touches, func(i, j int) bool {
+		if touches[i].ID != touches[j].ID {
+			return touches[i].ID < touches[j].ID
+		}
+		return touches[i].Button != TouchWheel && touches[j].Button == TouchWheel
+	})
+	return touches
+}
+
+// KnobPosition returns how far a knob has been turned since the
+// device was created (or since SetKnobPosition), in ticks.  Clockwise
+// turns count up and counter-clockwise turns count down.  The
+// position counts the ticks reported by the device, before any
+// acceleration.
+func (d *Device) KnobPosition(k Knob) int {
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+	if s := d.knobs[k]; s != nil {
+		return s.position
+	}
+	return 0
+}
+
+// SetKnobPosition sets the position returned by KnobPosition.
+func (d *Device) SetKnobPosition(k Knob, position int) {
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+	d.knobState(k).position = position
+}
+
+// InputState returns a snapshot of the device's buttons, touches,
+// and knobs, all taken at the same moment.
+func (d *Device) InputState() InputState {
+	// The locks are always taken in this order, and nothing else
+	// holds more than one of them.
+	d.pressedMutex.RLock()
+	defer d.pressedMutex.RUnlock()
+	d.touchMutex.RLock()
+	defer d.touchMutex.RUnlock()
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+
+	state := InputState{
+		Pressed: d.pressedButtons(),
+		Touches: d.activeTouches(),
+		Knobs:   map[Knob]int{},
+	}
+	for k, s := range d.knobs {
+		if s.position != 0 {
+			state.Knobs[k] = s.position
+		}
+	}
+	return state
+}
+
+// trackButton records a button press or release.
+func (d *Device) trackButton(b Button, state ButtonState) {
+	d.pressedMutex.Lock()
+	defer d.pressedMutex.Unlock()
+	if state == ButtonDown {
+		d.pressed[b] = true
+	} else {
+		delete(d.pressed, b)
+	}
+}
+
+// trackKnob adds a knob turn to the knob's position.
+func (d *Device) trackKnob(k Knob, delta int) {
+	d.knobMutex.Lock()
+	defer d.knobMutex.Unlock()
+	d.knobState(k).position += delta
+}
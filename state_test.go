@@ -0,0 +1,60 @@
+package loupedeck
+
+import (
+	"image"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestInputState(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0007"})
+	now := time.Now()
+
+	d.trackButton(Button3, ButtonDown)
+	d.trackButton(Button1, ButtonDown)
+	d.trackButton(Button2, ButtonDown)
+	d.trackButton(Button2, ButtonUp)
+	d.trackKnob(Knob1, 3)
+	d.trackKnob(Knob1, -1)
+	d.trackKnob(Knob2, 1)
+	d.trackKnob(Knob2, -1)
+	d.trackKnob(CTKnob, -4)
+	d.trackTouch(2, Touch1, image.Pt(70, 10), now)
+	d.trackTouch(1, TouchWheel, image.Pt(100, 100), now)
+	d.trackTouch(1, Touch5, image.Pt(70, 100), now)
+
+	s := d.InputState()
+	if want := []Button{Button1, Button3}; !slices.Equal(s.Pressed, want) {
+		t.Errorf("Pressed = %v, want %v", s.Pressed, want)
+	}
+	if want := map[Knob]int{Knob1: 2, CTKnob: -4}; !maps.Equal(s.Knobs, want) {
+		t.Errorf("Knobs = %v, want %v", s.Knobs, want)
+	}
+	var touches []TouchButton
+	for _, ts := range s.Touches {
+		touches = append(touches, ts.Button)
+	}
+	if want := []TouchButton{Touch5, TouchWheel, Touch1}; !slices.Equal(touches, want) {
+		t.Errorf("Touches are on %v, want %v", touches, want)
+	}
+
+	tests := []struct {
+		b    Button
+		want bool
+	}{
+		{Button1, true},
+		{Button2, false},
+		{Button3, true},
+		{Button4, false},
+	}
+	for _, tt := range tests {
+		if got := s.IsPressed(tt.b); got != tt.want {
+			t.Errorf("InputState.IsPressed(%v) = %v, want %v", tt.b, got, tt.want)
+		}
+		if got := d.IsPressed(tt.b); got != tt.want {
+			t.Errorf("Device.IsPressed(%v) = %v, want %v", tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,14 +1,18 @@
 package loupedeck
 
 import (
-	"log"
-	"time"
+	"image"
+	"log/slog"
+	"sort"
 )
 
 // TouchButton represents the regions of the touchpad on the Loupedeck Live.
 type TouchButton uint16
 
 const (
+	// TouchNone indicates a touch outside of any key, such as
+	// the margins on either side of the Live S's keys.
+	TouchNone  TouchButton = 0
 	Touch1     TouchButton = 1
 	Touch2     TouchButton = 2
 	Touch3     TouchButton = 3
@@ -21,8 +25,12 @@ const (
 	Touch10    TouchButton = 10
 	Touch11    TouchButton = 11
 	Touch12    TouchButton = 12
+	Touch13    TouchButton = 13 // Touch13-15 are only present on the Live S
+	Touch14    TouchButton = 14
+	Touch15    TouchButton = 15
 	TouchLeft  TouchButton = 101 // TouchLeft indicates that the left touchscreen area has been touched
 	TouchRight TouchButton = 102 // TouchRight indicates that hte right touchscreen area has been touched
+	TouchWheel TouchButton = 103 // TouchWheel indicates that the CT's wheel display has been touched
 )
 
 // TouchFunc is a function signature used for callbacks on TouchButton
@@ -34,43 +42,36 @@ const (
 //   - The Y location touched (relative to the whole display)
 type TouchFunc func(TouchButton, ButtonState, uint16, uint16)
 
-// BindTouch sets a callback for actions on a specific
+// BindTouch adds a callback for actions on a specific
 // TouchButton.  When the TouchButton is pushed down, then the
-// provided TouchFunc is called.
-func (d *Device) BindTouch(b TouchButton, f TouchFunc) {
-	d.touchBindings[b] = f
+// provided TouchFunc is called.  Any number of callbacks may be
+// bound to the same TouchButton.
+func (d *Device) BindTouch(b TouchButton, f TouchFunc) *Binding {
+	return d.bindings.touch.add(b, f)
 }
 
-// BindTouchUp sets a callback for actions on a specific
+// BindTouchUp adds a callback for actions on a specific
 // TouchButton.  When the TouchButton is released, then the
 // provided TouchFunc is called.
-func (d *Device) BindTouchUp(b TouchButton, f TouchFunc) {
-	d.touchUpBindings[b] = f
+func (d *Device) BindTouchUp(b TouchButton, f TouchFunc) *Binding {
+	return d.bindings.touchUp.add(b, f)
 }
 
-// Quick hack to decide if a touch is a click or a drag.  In an ideal
-// world, we'd also support double-click, but that either requires
-// knowing the future or delaying click messages until after a
-// specified time has passed without a second click, and there's no
-// room in the code for either today.
-func isClick(duration time.Duration, x, y int) bool {
-	if duration > 500*time.Millisecond {
-		return false
-	}
-
-	if x > 20 || x < -20 {
-		return false
-	}
-
-	if y > 20 || y < -20 {
-		return false
-	}
+// BindAnyTouch adds a callback that is called when any TouchButton
+// is pushed down.
+func (d *Device) BindAnyTouch(f TouchFunc) *Binding {
+	return d.bindings.touch.addAny(f)
+}
 
-	return true
+// BindAnyTouchUp adds a callback that is called when any
+// TouchButton is released.
+func (d *Device) BindAnyTouchUp(f TouchFunc) *Binding {
+	return d.bindings.touchUp.addAny(f)
 }
 
-// touchCoordToButton translates an x,y coordinate on the
-// touchscreen to a TouchButton.
+// CoordToTouchButton translates an x,y coordinate on the
+// touchscreen to a TouchButton, using the Loupedeck Live's layout.
+// Use Device.CoordToTouchButton for other models.
 func CoordToTouchButton(x, y uint16) TouchButton {
 	switch {
 	case x < 60:
@@ -86,8 +87,14 @@ func CoordToTouchButton(x, y uint16) TouchButton {
 	return TouchButton(uint16(Touch1) + x + 4*y)
 }
 
-// touchToXY turns a specific TouchButton into a set of x,y +
-// Display addresses, for use with the Draw function.
+// ToCoord turns a specific TouchButton into a set of x,y
+// coordinates on the "main" display, for use with the Draw function.
+// It returns -1, -1 for buttons that aren't one of the Loupedeck
+// Live's 12 keys.
+//
+// Deprecated: ToCoord only knows the Loupedeck Live's layout, and
+// ignores the device's orientation.  Use Device.DrawTouchKey or
+// Device.TouchKeyRect instead, which work with every model.
 func (b *TouchButton) ToCoord() (int, int) {
 	switch *b {
 	case Touch1:
@@ -116,6 +123,115 @@ func (b *TouchButton) ToCoord() (int, int) {
 		return 270, 180
 	}
 
-	log.Fatalln("Unknown TouchButton", b)
+	slog.Error("Unknown TouchButton", "button", *b)
 	return -1, -1
 }
+
+// updateTouchKeys recomputes the logical position of each
+// TouchButton from the model's layout and the current orientation.
+// Keys are numbered in reading order as the user sees them, so
+// Touch1 is always the top-left key.
+func (d *Device) updateTouchKeys() {
+	w, h := d.surfaceSize()
+	o := d.orientation
+	t := d.model.touch
+
+	keys := t.Keys()
+	for i, k := range keys {
+		keys[i] = o.RectToLogical(k, w, h)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		return readsBefore(keys[a].Min, keys[b].Min)
+	})
+	d.touchKeys = keys
+
+	d.touchLeft = o.RectToLogical(t.LeftStrip, w, h)
+	d.touchRight = o.RectToLogical(t.RightStrip, w, h)
+	if d.sidesSwapped() {
+		d.touchLeft, d.touchRight = d.touchRight, d.touchLeft
+	}
+}
+
+// TouchButtons returns the touch keys present on the connected
+// model, not including the side strips or wheel.
+func (d *Device) TouchButtons() []TouchButton {
+	b := make([]TouchButton, len(d.touchKeys))
+	for i := range d.touchKeys {
+		b[i] = Touch1 + TouchButton(i)
+	}
+	return b
+}
+
+// TouchKeyRect returns the area covered by a TouchButton, in
+// touchscreen coordinates as seen in the device's current
+// orientation.  TouchWheel is reported in "dial" display
+// coordinates.  The rectangle is empty if the connected model
+// doesn't have the requested TouchButton.
+func (d *Device) TouchKeyRect(b TouchButton) image.Rectangle {
+	switch b {
+	case TouchLeft:
+		return d.touchLeft
+	case TouchRight:
+		return d.touchRight
+	case TouchWheel:
+		return d.model.touch.Wheel
+	}
+	i := int(b - Touch1)
+	if b < Touch1 || i >= len(d.touchKeys) {
+		return image.Rectangle{}
+	}
+	return d.touchKeys[i]
+}
+
+// CoordToTouchButton translates an x,y coordinate on the
+// touchscreen, as seen in the device's current orientation, to a
+// TouchButton.  It returns TouchNone if the coordinate isn't on any
+// key.
+func (d *Device) CoordToTouchButton(x, y uint16) TouchButton {
+	p := image.Pt(int(x), int(y))
+	switch {
+	case p.In(d.touchLeft):
+		return TouchLeft
+	case p.In(d.touchRight):
+		return TouchRight
+	}
+	for i, k := range d.touchKeys {
+		if p.In(k) {
+			return Touch1 + TouchButton(i)
+		}
+	}
+	return TouchNone
+}
+
+// DrawTouchKey draws an image onto the display behind a TouchButton,
+// aligned with the top-left corner of the key.  The image should
+// normally be the size of TouchKeyRect(b).  Keys that don't fit on
+// the "main" display, such as the Live S's outer columns, are drawn
+// on "all".
+func (d *Device) DrawTouchKey(b TouchButton, im image.Image) {
+	var disp *Display
+	switch b {
+	case TouchLeft:
+		disp = d.GetDisplay("left")
+	case TouchRight:
+		disp = d.GetDisplay("right")
+	case TouchWheel:
+		disp = d.GetDisplay("dial")
+	default:
+		disp = d.GetDisplay("main")
+	}
+	r := d.TouchKeyRect(b)
+	if b != TouchWheel && disp != nil && !r.In(disp.logicalRect()) {
+		disp = d.GetDisplay("all")
+	}
+	if disp == nil || r.Empty() {
+		slog.Warn("Unable to draw touch key", "button", b, "model", d.Model)
+		return
+	}
+
+	origin := image.Point{}
+	if b != TouchWheel {
+		origin = disp.logicalRect().Min
+	}
+	disp.Draw(im, r.Min.X-origin.X, r.Min.Y-origin.Y)
+}
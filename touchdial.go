@@ -0,0 +1,160 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+)
+
+// TouchDial is a widget that shows up to three values on one of the
+// side strips, as bars next to the knobs that control them.  Turning
+// a knob adjusts its value, clicking the knob resets it, and touching
+// or dragging a finger up and down the strip next to a knob sets the
+// value directly, with the top of the strip being the maximum.
+type TouchDial struct {
+	WidgetBase
+	strip      TouchButton
+	knobs      [3]Knob
+	values     [3]*Watched[int]
+	stateMutex sync.Mutex
+	fg, bg     color.Color
+	container  *WidgetContainer
+	watchers   *Binding
+}
+
+// NewTouchDial creates a TouchDial on a side strip (TouchLeft or
+// TouchRight) and draws it.  The values are controlled by Knob1..3
+// for the left strip and Knob4..6 for the right strip, from top to
+// bottom.  Any of the values may be nil to leave that part of the
+// strip unused.  The values' ranges are set to min and max.
+func (d *Device) NewTouchDial(strip TouchButton, w1, w2, w3 *Watched[int], min, max int) *TouchDial {
+	t := &TouchDial{
+		strip:  strip,
+		knobs:  [3]Knob{Knob1, Knob2, Knob3},
+		values: [3]*Watched[int]{w1, w2, w3},
+		fg:     color.RGBA{255, 200, 0, 255},
+		bg:     color.Black,
+	}
+	if strip == TouchRight {
+		t.knobs = [3]Knob{Knob4, Knob5, Knob6}
+	}
+
+	var watchers []*Binding
+	for _, v := range t.values {
+		if v != nil {
+			v.SetRange(min, max)
+			watchers = append(watchers, v.AddWatcher(func(int) { t.Redraw() }))
+		}
+	}
+	t.watchers = joinBindings(watchers...)
+
+	t.container = NewWidgetContainer(d)
+	t.container.Add(strip, t)
+	for i, k := range t.knobs {
+		if t.values[i] != nil {
+			t.container.AttachKnob(k, t)
+		}
+	}
+	return t
+}
+
+// SetColors sets the colors of the bars and the background, and
+// redraws the TouchDial.
+func (t *TouchDial) SetColors(fg, bg color.Color) {
+	t.stateMutex.Lock()
+	t.fg, t.bg = fg, bg
+	t.stateMutex.Unlock()
+	t.Redraw()
+}
+
+// Close removes the TouchDial from the device.  The strip isn't
+// cleared.
+func (t *TouchDial) Close() {
+	t.container.Close()
+	t.watchers.Unbind()
+}
+
+// section returns the part of the strip used for value i, relative
+// to the strip.
+func (t *TouchDial) section(i int) image.Rectangle {
+	size := t.Bounds().Size()
+	h := size.Y / len(t.values)
+	return image.Rect(0, i*h, size.X, (i+1)*h)
+}
+
+// Draw implements Widget.
+func (t *TouchDial) Draw(d *Device) image.Image {
+	t.stateMutex.Lock()
+	fg, bg := t.fg, t.bg
+	t.stateMutex.Unlock()
+
+	im := solidImage(t.Bounds().Size(), bg)
+	for i, v := range t.values {
+		if v == nil {
+			continue
+		}
+		r := t.section(i).Inset(2)
+		track := color.RGBA{48, 48, 48, 255}
+		draw.Draw(im, r, &image.Uniform{track}, image.Point{}, draw.Src)
+
+		// Fill the bar from the bottom, and put the value in
+		// the top of the section.
+		fill := r
+		fill.Min.Y = r.Max.Y - int(v.Position()*float64(r.Dy()))
+		draw.Draw(im, fill, &image.Uniform{fg}, image.Point{}, draw.Src)
+
+		label := image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+r.Dy()/4)
+		text := textImage(d, label.Size(), fmt.Sprint(v.Get()), color.White, track)
+		draw.Draw(im, label, text, image.Point{}, draw.Over)
+	}
+	return im
+}
+
+// HandleEvent implements Widget.
+func (t *TouchDial) HandleEvent(e Event) bool {
+	switch e := e.(type) {
+	case KnobEvent:
+		if v := t.valueFor(e.Knob); v != nil {
+			v.Add(e.Delta)
+		}
+	case ButtonEvent:
+		if v := t.valueFor(Knob(e.Button)); v != nil && e.State == ButtonDown {
+			v.Reset()
+		}
+	case TouchEvent:
+		if e.Phase != TouchPhaseEnd {
+			t.drag(e.Session)
+		}
+	}
+	// The values' watchers redraw the TouchDial.
+	return false
+}
+
+func (t *TouchDial) valueFor(k Knob) *Watched[int] {
+	for i, x := range t.knobs {
+		if x == k {
+			return t.values[i]
+		}
+	}
+	return nil
+}
+
+// drag sets the value next to where the touch started from the
+// finger's current height.
+func (t *TouchDial) drag(s TouchSession) {
+	bounds := t.Bounds()
+	start := s.Start.Sub(bounds.Min)
+	for i, v := range t.values {
+		r := t.section(i).Inset(2)
+		if v == nil || start.Y < t.section(i).Min.Y || start.Y >= t.section(i).Max.Y || r.Dy() <= 0 {
+			continue
+		}
+		y := s.Position.Y - bounds.Min.Y
+		position := clamp01(float64(r.Max.Y-y) / float64(r.Dy()))
+		min, max := v.Range()
+		v.Set(min + int(position*float64(max-min)+0.5))
+		return
+	}
+}
@@ -0,0 +1,106 @@
+package loupedeck
+
+import (
+	"image"
+	"testing"
+)
+
+func TestTouchDial(t *testing.T) {
+	tests := []struct {
+		name  string
+		strip TouchButton
+		// input is sent to the dial's container.  top and bottom
+		// are the top and bottom of the strip's first section.
+		input func(c *WidgetContainer, top, bottom image.Point)
+		want1 int
+		want3 int
+	}{
+		{
+			name:  "knob",
+			strip: TouchLeft,
+			input: func(c *WidgetContainer, _, _ image.Point) {
+				c.knob(Knob1, 5)
+				c.knob(Knob3, -2)
+			},
+			want1: 55,
+			want3: 18,
+		},
+		{
+			name:  "right strip knobs",
+			strip: TouchRight,
+			input: func(c *WidgetContainer, _, _ image.Point) {
+				c.knob(Knob1, 5)
+				c.knob(Knob4, 1)
+				c.knob(Knob6, 1)
+			},
+			want1: 51,
+			want3: 21,
+		},
+		{
+			name:  "clamped",
+			strip: TouchLeft,
+			input: func(c *WidgetContainer, _, _ image.Point) {
+				c.knob(Knob1, 80)
+			},
+			want1: 100,
+			want3: 20,
+		},
+		{
+			name:  "reset",
+			strip: TouchLeft,
+			input: func(c *WidgetContainer, _, _ image.Point) {
+				c.knob(Knob1, 5)
+				c.button(KnobButton1, ButtonDown)
+			},
+			want1: 50,
+			want3: 20,
+		},
+		{
+			name:  "unused knob",
+			strip: TouchLeft,
+			input: func(c *WidgetContainer, _, _ image.Point) {
+				c.knob(Knob2, 5)
+			},
+			want1: 50,
+			want3: 20,
+		},
+		{
+			name:  "drag to the top",
+			strip: TouchLeft,
+			input: func(c *WidgetContainer, top, bottom image.Point) {
+				c.touch(TouchPhaseBegin, TouchSession{Button: TouchLeft, Start: bottom, Position: bottom})
+				c.touch(TouchPhaseMove, TouchSession{Button: TouchLeft, Start: bottom, Position: top})
+			},
+			want1: 100,
+			want3: 20,
+		},
+		{
+			name:  "drag past the bottom",
+			strip: TouchLeft,
+			input: func(c *WidgetContainer, top, bottom image.Point) {
+				c.touch(TouchPhaseBegin, TouchSession{Button: TouchLeft, Start: top, Position: top})
+				c.touch(TouchPhaseMove, TouchSession{Button: TouchLeft, Start: top, Position: bottom.Add(image.Pt(0, 50))})
+			},
+			want1: 0,
+			want3: 20,
+		},
+	}
+	for _, tt := range tests {
+		d := newTestDevice(t, "0004")
+		w1, w3 := NewWatchedInt(50), NewWatchedInt(20)
+		dial := d.NewTouchDial(tt.strip, w1, nil, w3, 0, 100)
+
+		r := dial.section(0).Inset(2).Add(dial.Bounds().Min)
+		top := image.Pt(r.Min.X+1, r.Min.Y)
+		bottom := image.Pt(r.Min.X+1, r.Max.Y-1)
+		tt.input(dial.container, top, bottom)
+
+		if got := w1.Get(); got != tt.want1 {
+			t.Errorf("%s: first value is %d, want %d", tt.name, got, tt.want1)
+		}
+		if got := w3.Get(); got != tt.want3 {
+			t.Errorf("%s: third value is %d, want %d", tt.name, got, tt.want3)
+		}
+		dial.Close()
+	}
+}
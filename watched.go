@@ -0,0 +1,288 @@
+package loupedeck
+
+import (
+	"math"
+	"sync"
+)
+
+// Number is the set of types that can be held in a Watched value.
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// BoundsMode controls what a Watched value does when it is moved
+// past its minimum or maximum.
+type BoundsMode uint8
+
+const (
+	// Clamp stops the value at its minimum or maximum.
+	Clamp BoundsMode = 0
+	// Wrap wraps the value around to the other end of its range,
+	// like a hue or an angle.
+	Wrap BoundsMode = 1
+)
+
+// WatchFunc is a function signature used for callbacks when a
+// Watched value changes.  It's called with the new value.
+type WatchFunc[T Number] func(T)
+
+// Watched is a bounded numeric value that calls its watchers
+// whenever it changes.  It's the usual way to connect a knob to a
+// parameter: the knob adjusts the value, and the watchers update
+// whatever the parameter controls (and redraw the display).
+//
+// Watched values are safe for concurrent use.
+type Watched[T Number] struct {
+	mutex    sync.Mutex
+	value    T
+	min, max T
+	step     T
+	def      T
+	mode     BoundsMode
+	watchers handlerList[struct{}, WatchFunc[T]]
+
+	// curve, if set, maps knob positions onto values; position is
+	// the knob's position in [0,1], and ticks is the number of
+	// knob ticks from one end of the range to the other.
+	curve    Curve
+	ticks    int
+	position float64
+}
+
+// NewWatched creates a new Watched value between min and max
+// (inclusive), which moves by step for each knob tick.  The initial
+// value is also used as the default for Reset.
+func NewWatched[T Number](value, min, max, step T) *Watched[T] {
+	w := &Watched[T]{min: min, max: max, step: step}
+	w.value = w.normalize(float64(value))
+	w.def = w.value
+	return w
+}
+
+// NewWatchedInt creates a new Watched int with no practical bounds
+// and a step of 1.  Use SetRange to bound it.
+func NewWatchedInt(value int) *Watched[int] {
+	return NewWatched(value, math.MinInt, math.MaxInt, 1)
+}
+
+// NewWatchedFloat creates a new Watched float64 between 0 and 1,
+// with a step of 0.01.
+func NewWatchedFloat(value float64) *Watched[float64] {
+	return NewWatched(value, 0, 1, 0.01)
+}
+
+// Get returns the current value.
+func (w *Watched[T]) Get() T {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.value
+}
+
+// Set changes the value, clamping or wrapping it into range, and
+// calls the watchers if it changed.
+func (w *Watched[T]) Set(value T) {
+	w.update(func() float64 { return float64(value) }, false)
+}
+
+// Add moves the value by steps times the value's step.  Negative
+// steps move it down.
+//
+// When a Curve is set, Add moves the knob position by steps ticks
+// instead, and the value follows the curve.
+func (w *Watched[T]) Add(steps int) {
+	w.update(func() float64 {
+		if w.curve == nil {
+			return float64(w.value) + float64(steps)*float64(w.step)
+		}
+		p := w.position + float64(steps)/float64(w.ticks)
+		if w.mode == Wrap {
+			p -= math.Floor(p)
+		}
+		w.position = clamp01(p)
+		return float64(w.min) + w.curve.Map(w.position)*(float64(w.max)-float64(w.min))
+	}, true)
+}
+
+// Reset sets the value back to its default.
+func (w *Watched[T]) Reset() {
+	w.update(func() float64 { return float64(w.def) }, false)
+}
+
+// update sets the value to the result of f, which is called with
+// the mutex held, and notifies the watchers if it changed.  Unless
+// keepPosition is set and there's a curve, the curve position is
+// moved to match the new value.
+func (w *Watched[T]) update(f func() float64, keepPosition bool) {
+	w.mutex.Lock()
+	old := w.value
+	w.value = w.normalize(f())
+	value := w.value
+	if !keepPosition || w.curve == nil {
+		w.syncPosition()
+	}
+	w.mutex.Unlock()
+
+	if value != old {
+		w.notify(value)
+	}
+}
+
+func (w *Watched[T]) notify(value T) {
+	for _, f := range w.watchers.get(struct{}{}) {
+		f(value)
+	}
+}
+
+// isFloat returns true if T is a floating-point type.
+func (w *Watched[T]) isFloat() bool {
+	return T(1)/T(2) != 0
+}
+
+// normalize clamps or wraps v into the value's range.  The
+// arithmetic is done with float64s so that unsigned types can move
+// below zero before being brought back into range.
+func (w *Watched[T]) normalize(v float64) T {
+	min, max := float64(w.min), float64(w.max)
+	if !w.isFloat() {
+		v = math.Round(v)
+	}
+
+	if w.mode == Wrap && max > min && (v < min || v > max) {
+		span := max - min
+		if !w.isFloat() {
+			span++
+		}
+		v = math.Mod(v-min, span)
+		if v < 0 {
+			v += span
+		}
+		return T(min + v)
+	}
+	// Return the bounds themselves rather than converting back
+	// from float64, which can't represent every int64 exactly.
+	switch {
+	case v <= min:
+		return w.min
+	case v >= max:
+		return w.max
+	}
+	return T(v)
+}
+
+// Default returns the value used by Reset.
+func (w *Watched[T]) Default() T {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.def
+}
+
+// SetDefault changes the value used by Reset.
+func (w *Watched[T]) SetDefault(def T) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.def = w.normalize(float64(def))
+}
+
+// Range returns the minimum and maximum values.
+func (w *Watched[T]) Range() (T, T) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.min, w.max
+}
+
+// SetRange changes the minimum and maximum values, moving the
+// current value into range if needed.
+func (w *Watched[T]) SetRange(min, max T) {
+	w.update(func() float64 {
+		w.min, w.max = min, max
+		w.def = w.normalize(float64(w.def))
+		return float64(w.value)
+	}, false)
+}
+
+// Step returns the amount that the value moves per knob tick.
+func (w *Watched[T]) Step() T {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.step
+}
+
+// SetStep changes the amount that the value moves per knob tick.
+func (w *Watched[T]) SetStep(step T) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.step = step
+}
+
+// SetMode sets whether the value clamps or wraps at the ends of its
+// range.
+func (w *Watched[T]) SetMode(mode BoundsMode) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.mode = mode
+}
+
+// SetCurve sets a response curve for the value.  Knob turns (via
+// Add) then move a position between 0 and 1, taking ticks knob ticks
+// to cover the whole range, and the value is mapped from that
+// position through the curve.  Setting the value directly moves the
+// position to match, using the curve's inverse.  Passing a nil
+// Curve returns to moving by Step per tick.
+func (w *Watched[T]) SetCurve(c Curve, ticks int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.curve = c
+	w.ticks = max(ticks, 1)
+	w.syncPosition()
+}
+
+// Position returns the knob position of the value, between 0 and 1.
+// Without a curve this is simply where the value sits in its range.
+func (w *Watched[T]) Position() float64 {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	if w.curve == nil {
+		return w.fraction()
+	}
+	return w.position
+}
+
+// fraction returns where the value sits in its range, between 0
+// and 1.  The caller must hold the mutex.
+func (w *Watched[T]) fraction() float64 {
+	min, max := float64(w.min), float64(w.max)
+	if max <= min {
+		return 0
+	}
+	return (float64(w.value) - min) / (max - min)
+}
+
+// syncPosition moves the curve position to match the current
+// value.  The caller must hold the mutex.
+func (w *Watched[T]) syncPosition() {
+	if w.curve != nil {
+		w.position = clamp01(w.curve.Inverse(w.fraction()))
+	}
+}
+
+// AddWatcher adds a callback that is called with the new value
+// whenever the value changes.
+func (w *Watched[T]) AddWatcher(f WatchFunc[T]) *Binding {
+	return w.watchers.addAny(f)
+}
+
+// BindKnob connects the value to a knob on a device: turning the
+// knob moves the value by one step per tick, and clicking the knob
+// resets it to its default.  The returned Binding disconnects both.
+func (w *Watched[T]) BindKnob(d *Device, k Knob) *Binding {
+	return joinBindings(
+		d.BindKnob(k, func(_ Knob, delta int) {
+			w.Add(delta)
+		}),
+		d.BindButton(Button(k), func(Button, ButtonState) {
+			w.Reset()
+		}),
+	)
+}
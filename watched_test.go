@@ -0,0 +1,104 @@
+package loupedeck
+
+import (
+	"math"
+	"runtime"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestWatchedInt(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  BoundsMode
+		start int
+		op    func(*Watched[int])
+		want  int
+	}{
+		{"add", Clamp, 5, func(w *Watched[int]) { w.Add(2) }, 7},
+		{"subtract", Clamp, 5, func(w *Watched[int]) { w.Add(-2) }, 3},
+		{"clamp high", Clamp, 9, func(w *Watched[int]) { w.Add(5) }, 10},
+		{"clamp low", Clamp, 1, func(w *Watched[int]) { w.Add(-5) }, 0},
+		{"set out of range", Clamp, 1, func(w *Watched[int]) { w.Set(50) }, 10},
+		{"wrap high", Wrap, 9, func(w *Watched[int]) { w.Add(3) }, 1},
+		{"wrap low", Wrap, 1, func(w *Watched[int]) { w.Add(-3) }, 9},
+		{"wrap exactly", Wrap, 10, func(w *Watched[int]) { w.Add(1) }, 0},
+		{"reset", Clamp, 4, func(w *Watched[int]) { w.Add(3); w.Reset() }, 4},
+		{"range shrinks", Clamp, 8, func(w *Watched[int]) { w.SetRange(0, 5) }, 5},
+	}
+	for _, tt := range tests {
+		w := NewWatched(tt.start, 0, 10, 1)
+		w.SetMode(tt.mode)
+		tt.op(w)
+		if got := w.Get(); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWatchedUnsigned(t *testing.T) {
+	w := NewWatched[uint8](2, 0, 255, 5)
+	w.Add(-1)
+	if got := w.Get(); got != 0 {
+		t.Errorf("clamped uint8 = %d, want 0", got)
+	}
+	w.SetMode(Wrap)
+	w.Add(-1)
+	if got := w.Get(); got != 251 {
+		t.Errorf("wrapped uint8 = %d, want 251", got)
+	}
+}
+
+func TestWatchedWatchers(t *testing.T) {
+	w := NewWatched(0.5, 0, 1, 0.25)
+	var seen []float64
+	b := w.AddWatcher(func(v float64) { seen = append(seen, v) })
+	w.Add(1)
+	w.Set(0.75) // Unchanged, so not reported.
+	w.Add(4)
+	b.Unbind()
+	w.Reset()
+	if want := []float64{0.75, 1}; !slices.Equal(seen, want) {
+		t.Errorf("watchers saw %v, want %v", seen, want)
+	}
+}
+
+func TestWatchedCurve(t *testing.T) {
+	w := NewWatched(0.0, 0, 100, 1)
+	w.SetCurve(GammaCurve{Gamma: 2}, 10)
+	w.Add(5)
+	if got := w.Get(); math.Abs(got-25) > 1e-9 {
+		t.Errorf("half way along a gamma 2 curve = %v, want 25", got)
+	}
+	if got := w.Position(); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("Position() = %v, want 0.5", got)
+	}
+	w.Set(81)
+	if got := w.Position(); math.Abs(got-0.9) > 1e-9 {
+		t.Errorf("Position() after Set(81) = %v, want 0.9", got)
+	}
+}
+
+func TestWatchedCurveConcurrent(t *testing.T) {
+	w := NewWatched(0.0, 0, 100, 1)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			w.SetCurve(GammaCurve{Gamma: 2}, 10)
+			w.SetCurve(nil, 0)
+			runtime.Gosched()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		w.Add(1)
+		w.Add(-1)
+		runtime.Gosched()
+	}
+	wg.Wait()
+	if got := w.Get(); got < 0 || got > 100 {
+		t.Errorf("Get() = %v, want a value from 0 to 100", got)
+	}
+}
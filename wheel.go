@@ -0,0 +1,107 @@
+package loupedeck
+
+import (
+	"image"
+)
+
+// WheelFunc is a function signature used for callbacks when the
+// Loupedeck CT's wheel is turned.  It's called with the number of
+// ticks turned, as for a KnobFunc, and the wheel's position as
+// returned by WheelPosition.
+type WheelFunc func(delta, position int)
+
+// WheelTouchEvent describes a touch on the Loupedeck CT's wheel.
+type WheelTouchEvent struct {
+	Phase TouchPhase
+	// X and Y are the touch position, in "dial" display
+	// coordinates as seen in the device's current orientation.
+	X, Y int
+	// Angle is the direction of the touch from the center of
+	// the wheel, in degrees clockwise from the top, from 0 up to
+	// (but not including) 360.
+	Angle float64
+	// Radius is the distance of the touch from the center of the
+	// wheel, in pixels.
+	Radius float64
+	// Session is the TouchSession for the finger.
+	Session TouchSession
+}
+
+// WheelTouchFunc is a function signature used for callbacks on
+// touches on the Loupedeck CT's wheel.
+type WheelTouchFunc func(WheelTouchEvent)
+
+// BindWheel adds a callback for turns of the Loupedeck CT's wheel.
+// Turns are also sent to any KnobFuncs bound to CTKnob.
+func (d *Device) BindWheel(f WheelFunc) *Binding {
+	return d.bindings.wheel.addAny(f)
+}
+
+// BindWheelTouch adds a callback for every phase of every touch on
+// the Loupedeck CT's wheel.  Touches on the wheel are also sent to
+// TouchFuncs bound to TouchWheel, to TouchSessionFuncs, and to any
+// TouchRegions on the "dial" display.
+func (d *Device) BindWheelTouch(f WheelTouchFunc) *Binding {
+	return d.bindings.wheelTouch.addAny(f)
+}
+
+// WheelPosition returns how far the Loupedeck CT's wheel has been
+// turned, in ticks.  It's the same as KnobPosition(CTKnob), and can
+// be changed with SetKnobPosition.
+func (d *Device) WheelPosition() int {
+	return d.KnobPosition(CTKnob)
+}
+
+// WheelPolar returns the angle and radius of a point on the "dial"
+// display, relative to the center of the wheel.  The angle is in
+// degrees clockwise from the top, from 0 up to (but not including)
+// 360.
+func (d *Device) WheelPolar(p image.Point) (angle, radius float64) {
+	c := rectCenter(d.model.touch.Wheel)
+	return xyToPolar(float64(p.X)-c[0], float64(p.Y)-c[1])
+}
+
+// wheelToLogical maps a touch on the wheel reported by the hardware
+// onto the logical "dial" display coordinates seen by the user.
+func (d *Device) wheelToLogical(x, y uint16) (uint16, uint16) {
+	if d.orientation == Rotate0 {
+		return x, y
+	}
+	r := d.model.touch.Wheel
+	lx, ly := d.orientation.ToLogical(int(x), int(y), r.Dx(), r.Dy())
+	return uint16(lx), uint16(ly)
+}
+
+// wheelCalls returns the WheelFunc callbacks for a turn of the
+// wheel.
+func (d *Device) wheelCalls(delta int) []func() {
+	fs := d.bindings.wheel.get(struct{}{})
+	if len(fs) == 0 {
+		return nil
+	}
+	position := d.WheelPosition()
+	calls := make([]func(), len(fs))
+	for i, f := range fs {
+		f := f
+		calls[i] = func() { f(delta, position) }
+	}
+	return calls
+}
+
+// dispatchWheelTouch calls the WheelTouchFunc callbacks for a touch
+// on the wheel.
+func (d *Device) dispatchWheelTouch(phase TouchPhase, s *TouchSession) {
+	angle, radius := d.WheelPolar(s.Position)
+	e := WheelTouchEvent{
+		Phase:   phase,
+		X:       s.Position.X,
+		Y:       s.Position.Y,
+		Angle:   angle,
+		Radius:  radius,
+		Session: *s,
+	}
+	for _, f := range d.bindings.wheelTouch.get(struct{}{}) {
+		f := f
+		d.run(controlKey{touchControl, uint32(TouchWheel)}, func() { f(e) })
+	}
+}
@@ -0,0 +1,61 @@
+package loupedeck
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestWheelTouch(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation Orientation
+		x, y        uint16
+		// want is the logical position, and angle and radius
+		// its polar coordinates.
+		want   image.Point
+		angle  float64
+		radius float64
+	}{
+		{"top", Rotate0, 120, 20, image.Pt(120, 20), 0, 100},
+		{"right", Rotate0, 220, 120, image.Pt(220, 120), 90, 100},
+		{"bottom left", Rotate0, 20, 220, image.Pt(20, 220), 225, math.Sqrt2 * 100},
+		{"upside down", Rotate180, 120, 20, image.Pt(119, 219), 180.6, 99},
+		{"rotated", Rotate90, 120, 20, image.Pt(219, 120), 90, 99},
+	}
+	for _, tt := range tests {
+		d := CreateDevice(&SerialWebSockConn{Product: "0007"})
+		d.SetOrientation(tt.orientation)
+		x, y := d.wheelToLogical(tt.x, tt.y)
+		p := image.Pt(int(x), int(y))
+		if p != tt.want {
+			t.Errorf("%s: wheelToLogical(%d, %d) = %v, want %v", tt.name, tt.x, tt.y, p, tt.want)
+			continue
+		}
+		angle, radius := d.WheelPolar(p)
+		if math.Abs(angle-tt.angle) > 0.5 || math.Abs(radius-tt.radius) > 0.5 {
+			t.Errorf("%s: WheelPolar(%v) = %.1f, %.1f, want %.1f, %.1f", tt.name, p, angle, radius, tt.angle, tt.radius)
+		}
+	}
+}
+
+func TestBindWheel(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0007"})
+	var gotDelta, gotPosition int
+	b := d.BindWheel(func(delta, position int) {
+		gotDelta, gotPosition = delta, position
+	})
+	d.SetKnobPosition(CTKnob, 10)
+
+	for _, f := range d.wheelCalls(3) {
+		f()
+	}
+	if gotDelta != 3 || gotPosition != 10 {
+		t.Errorf("WheelFunc called with %d, %d, want 3, 10", gotDelta, gotPosition)
+	}
+
+	b.Unbind()
+	if calls := d.wheelCalls(1); len(calls) != 0 {
+		t.Errorf("got %d wheel calls after Unbind, want 0", len(calls))
+	}
+}
@@ -0,0 +1,248 @@
+package loupedeck
+
+import (
+	"image"
+	"sync"
+)
+
+// Widget is something drawn on a touch key or side strip that may
+// react to input, such as a button or a gauge.  Widgets are placed on
+// the device with a WidgetContainer, which draws them and passes them
+// events.
+//
+// Most widgets embed WidgetBase, which provides Bounds, SetBounds,
+// and SetRedraw, and only need to implement Draw and HandleEvent.
+type Widget interface {
+	// Bounds returns the area covered by the widget, in the
+	// coordinates returned by Device.TouchKeyRect.
+	Bounds() image.Rectangle
+	// SetBounds is called by the container when the widget is
+	// placed.
+	SetBounds(image.Rectangle)
+	// SetRedraw is called by the container with a function that
+	// redraws the widget.  Widgets call it whenever their state
+	// changes.
+	SetRedraw(func())
+	// Draw returns an image of the widget, the size of Bounds.
+	Draw(d *Device) image.Image
+	// HandleEvent is called with TouchEvents for touches on the
+	// widget, and with KnobEvents and ButtonEvents from any knob
+	// attached to it.  It returns true if the widget needs to be
+	// redrawn.
+	HandleEvent(e Event) bool
+}
+
+// WidgetBase implements the placement and redraw parts of Widget.
+// It's meant to be embedded in widgets.
+type WidgetBase struct {
+	mutex  sync.Mutex
+	bounds image.Rectangle
+	redraw func()
+}
+
+// Bounds returns the area covered by the widget.
+func (w *WidgetBase) Bounds() image.Rectangle {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.bounds
+}
+
+// SetBounds sets the area covered by the widget.
+func (w *WidgetBase) SetBounds(r image.Rectangle) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.bounds = r
+}
+
+// SetRedraw sets the function called by Redraw.
+func (w *WidgetBase) SetRedraw(f func()) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.redraw = f
+}
+
+// Redraw redraws the widget, if it has been placed on a device.
+func (w *WidgetBase) Redraw() {
+	w.mutex.Lock()
+	f := w.redraw
+	w.mutex.Unlock()
+	if f != nil {
+		f()
+	}
+}
+
+// WidgetContainer places Widgets on a device's touch keys and side
+// strips.  It draws each widget when it's added and whenever the
+// widget asks to be redrawn, and passes it the touches on its area.
+// Knobs can also be attached to widgets, so that turning a knob
+// adjusts a gauge, for instance.
+type WidgetContainer struct {
+	device  *Device
+	mutex   sync.Mutex
+	widgets map[TouchButton]Widget
+	knobs   map[Knob]Widget
+	binding *Binding
+}
+
+// NewWidgetContainer creates a WidgetContainer for a device.
+func NewWidgetContainer(d *Device) *WidgetContainer {
+	c := &WidgetContainer{
+		device:  d,
+		widgets: map[TouchButton]Widget{},
+		knobs:   map[Knob]Widget{},
+	}
+	c.binding = joinBindings(
+		d.BindTouchSession(c.touch),
+		d.BindAnyKnob(c.knob),
+		d.BindAnyButton(c.button),
+		d.BindAnyButtonUp(c.button),
+	)
+	return c
+}
+
+// Add places a widget on a TouchButton (a touch key, TouchLeft,
+// TouchRight, or TouchWheel), replacing any widget already there, and
+// draws it.
+func (c *WidgetContainer) Add(b TouchButton, w Widget) {
+	r := c.device.TouchKeyRect(b)
+	w.SetBounds(r)
+	w.SetRedraw(func() { c.draw(b, w) })
+
+	c.mutex.Lock()
+	old := c.widgets[b]
+	c.widgets[b] = w
+	c.mutex.Unlock()
+
+	if old != nil && old != w {
+		old.SetRedraw(nil)
+	}
+	c.draw(b, w)
+}
+
+// Remove takes the widget off of a TouchButton.  The display isn't
+// cleared.
+func (c *WidgetContainer) Remove(b TouchButton) {
+	c.mutex.Lock()
+	w := c.widgets[b]
+	delete(c.widgets, b)
+	c.mutex.Unlock()
+
+	if w != nil {
+		w.SetRedraw(nil)
+	}
+}
+
+// Widget returns the widget on a TouchButton, or nil.
+func (c *WidgetContainer) Widget(b TouchButton) Widget {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.widgets[b]
+}
+
+// AttachKnob sends turns and clicks of a knob to a widget.  Passing
+// a nil Widget detaches the knob.
+func (c *WidgetContainer) AttachKnob(k Knob, w Widget) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if w == nil {
+		delete(c.knobs, k)
+		return
+	}
+	c.knobs[k] = w
+}
+
+// Redraw draws every widget again, such as after the device's
+// orientation has changed.
+func (c *WidgetContainer) Redraw() {
+	c.mutex.Lock()
+	widgets := make(map[TouchButton]Widget, len(c.widgets))
+	for b, w := range c.widgets {
+		widgets[b] = w
+	}
+	c.mutex.Unlock()
+
+	for b, w := range widgets {
+		w.SetBounds(c.device.TouchKeyRect(b))
+		c.draw(b, w)
+	}
+}
+
+// Close removes all of the widgets and stops passing them events.
+func (c *WidgetContainer) Close() {
+	c.binding.Unbind()
+	c.mutex.Lock()
+	widgets := c.widgets
+	c.widgets = map[TouchButton]Widget{}
+	c.knobs = map[Knob]Widget{}
+	c.mutex.Unlock()
+
+	for _, w := range widgets {
+		w.SetRedraw(nil)
+	}
+}
+
+func (c *WidgetContainer) draw(b TouchButton, w Widget) {
+	c.mutex.Lock()
+	current := c.widgets[b] == w
+	c.mutex.Unlock()
+	if current {
+		c.device.DrawTouchKey(b, w.Draw(c.device))
+	}
+}
+
+// deliver passes an event to a widget, and redraws it if needed.
+func (c *WidgetContainer) deliver(w Widget, e Event) {
+	if w.HandleEvent(e) {
+		c.mutex.Lock()
+		var b TouchButton
+		found := false
+		for x, y := range c.widgets {
+			if y == w {
+				b, found = x, true
+				break
+			}
+		}
+		c.mutex.Unlock()
+		if found {
+			c.draw(b, w)
+		}
+	}
+}
+
+func (c *WidgetContainer) touch(phase TouchPhase, s TouchSession) {
+	c.mutex.Lock()
+	w := c.widgets[s.Button]
+	c.mutex.Unlock()
+	if w == nil {
+		return
+	}
+	c.deliver(w, TouchEvent{
+		EventInfo: c.device.eventInfo(),
+		Button:    s.Button,
+		Phase:     phase,
+		X:         uint16(s.Position.X),
+		Y:         uint16(s.Position.Y),
+		Session:   s,
+	})
+}
+
+func (c *WidgetContainer) knob(k Knob, delta int) {
+	c.mutex.Lock()
+	w := c.knobs[k]
+	c.mutex.Unlock()
+	if w != nil {
+		c.deliver(w, KnobEvent{EventInfo: c.device.eventInfo(), Knob: k, Delta: delta, RawDelta: delta})
+	}
+}
+
+func (c *WidgetContainer) button(b Button, state ButtonState) {
+	if b < KnobButton1 || b > KnobButton6 {
+		return
+	}
+	c.mutex.Lock()
+	w := c.knobs[Knob(b)]
+	c.mutex.Unlock()
+	if w != nil {
+		c.deliver(w, ButtonEvent{EventInfo: c.device.eventInfo(), Button: b, State: state})
+	}
+}
@@ -0,0 +1,142 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+)
+
+// testWidget records the events and draws it gets.
+type testWidget struct {
+	WidgetBase
+	mutex  sync.Mutex
+	events []string
+	draws  int
+	// redraw is returned from HandleEvent.
+	redraw bool
+}
+
+func (w *testWidget) Draw(d *Device) image.Image {
+	w.mutex.Lock()
+	w.draws++
+	w.mutex.Unlock()
+	return solidImage(w.Bounds().Size(), color.Black)
+}
+
+func (w *testWidget) HandleEvent(e Event) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	switch e := e.(type) {
+	case TouchEvent:
+		w.events = append(w.events, fmt.Sprintf("touch %d", e.Button))
+	case KnobEvent:
+		w.events = append(w.events, fmt.Sprintf("knob %d %d", e.Knob, e.Delta))
+	case ButtonEvent:
+		w.events = append(w.events, fmt.Sprintf("button %d %d", e.Button, e.State))
+	}
+	return w.redraw
+}
+
+func TestWidgetContainer(t *testing.T) {
+	tests := []struct {
+		name string
+		// input is sent to the container after the widget is
+		// added to Touch1 and attached to Knob1.
+		input      func(c *WidgetContainer)
+		redraw     bool
+		wantEvents []string
+		wantDraws  int
+	}{
+		{
+			name:      "added",
+			input:     func(c *WidgetContainer) {},
+			wantDraws: 1,
+		},
+		{
+			name: "touch",
+			input: func(c *WidgetContainer) {
+				c.touch(TouchPhaseBegin, TouchSession{Button: Touch1})
+				c.touch(TouchPhaseBegin, TouchSession{Button: Touch2})
+			},
+			wantEvents: []string{"touch 1"},
+			wantDraws:  1,
+		},
+		{
+			name: "knob",
+			input: func(c *WidgetContainer) {
+				c.knob(Knob1, 2)
+				c.knob(Knob2, 1)
+				c.button(KnobButton1, ButtonDown)
+				c.button(Button1, ButtonDown)
+			},
+			wantEvents: []string{"knob 1 2", "button 1 0"},
+			wantDraws:  1,
+		},
+		{
+			name: "redrawn after an event",
+			input: func(c *WidgetContainer) {
+				c.knob(Knob1, 1)
+			},
+			redraw:     true,
+			wantEvents: []string{"knob 1 1"},
+			wantDraws:  2,
+		},
+		{
+			name: "redraw",
+			input: func(c *WidgetContainer) {
+				c.Redraw()
+			},
+			wantDraws: 2,
+		},
+		{
+			name: "removed",
+			input: func(c *WidgetContainer) {
+				c.Remove(Touch1)
+				c.touch(TouchPhaseBegin, TouchSession{Button: Touch1})
+				c.Redraw()
+			},
+			wantDraws: 1,
+		},
+		{
+			name: "knob detached",
+			input: func(c *WidgetContainer) {
+				c.AttachKnob(Knob1, nil)
+				c.knob(Knob1, 1)
+			},
+			wantDraws: 1,
+		},
+		{
+			name: "closed",
+			input: func(c *WidgetContainer) {
+				c.Close()
+				c.touch(TouchPhaseBegin, TouchSession{Button: Touch1})
+				c.knob(Knob1, 1)
+			},
+			wantDraws: 1,
+		},
+	}
+	for _, tt := range tests {
+		d := newTestDevice(t, "0004")
+		c := NewWidgetContainer(d)
+		w := &testWidget{redraw: tt.redraw}
+		c.Add(Touch1, w)
+		c.AttachKnob(Knob1, w)
+		if w.Bounds() != d.TouchKeyRect(Touch1) {
+			t.Errorf("%s: widget bounds are %v, want %v", tt.name, w.Bounds(), d.TouchKeyRect(Touch1))
+		}
+
+		tt.input(c)
+
+		w.mutex.Lock()
+		if fmt.Sprint(w.events) != fmt.Sprint(tt.wantEvents) {
+			t.Errorf("%s: widget got events %q, want %q", tt.name, w.events, tt.wantEvents)
+		}
+		if w.draws != tt.wantDraws {
+			t.Errorf("%s: widget drawn %d times, want %d", tt.name, w.draws, tt.wantDraws)
+		}
+		w.mutex.Unlock()
+		c.Close()
+	}
+}
@@ -0,0 +1,451 @@
+package loupedeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log/slog"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// textImage draws text centered in an image of the given size, as
+// large as will fit.
+func textImage(d *Device, size image.Point, text string, fg, bg color.Color) image.Image {
+	im, err := d.TextInBox(size.X, size.Y, text, fg, bg)
+	if err != nil {
+		slog.Warn("Unable to draw text", "text", text, "err", err)
+		return solidImage(size, bg)
+	}
+	return im
+}
+
+func solidImage(size image.Point, c color.Color) *image.RGBA {
+	im := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(im, im.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return im
+}
+
+// drawIcon draws icon centered in r, scaled down to fit if needed.
+func drawIcon(dst draw.Image, r image.Rectangle, icon image.Image) {
+	ib := icon.Bounds()
+	if ib.Empty() || r.Empty() {
+		return
+	}
+	scale := min(1, float64(r.Dx())/float64(ib.Dx()), float64(r.Dy())/float64(ib.Dy()))
+	w, h := int(float64(ib.Dx())*scale), int(float64(ib.Dy())*scale)
+	at := r.Min.Add(image.Pt((r.Dx()-w)/2, (r.Dy()-h)/2))
+	xdraw.CatmullRom.Scale(dst, image.Rect(0, 0, w, h).Add(at), icon, ib, draw.Over, nil)
+}
+
+// touchedInside returns true if a TouchEvent ended inside the
+// widget.
+func touchedInside(w Widget, e TouchEvent) bool {
+	return image.Pt(int(e.X), int(e.Y)).In(w.Bounds())
+}
+
+// Label is a widget that shows a line of text.
+type Label struct {
+	WidgetBase
+	stateMutex sync.Mutex
+	text       string
+	fg, bg     color.Color
+}
+
+// NewLabel creates a new Label.
+func NewLabel(text string, fg, bg color.Color) *Label {
+	return &Label{text: text, fg: fg, bg: bg}
+}
+
+// Text returns the label's text.
+func (l *Label) Text() string {
+	l.stateMutex.Lock()
+	defer l.stateMutex.Unlock()
+	return l.text
+}
+
+// SetText changes the label's text and redraws it.
+func (l *Label) SetText(text string) {
+	l.stateMutex.Lock()
+	l.text = text
+	l.stateMutex.Unlock()
+	l.Redraw()
+}
+
+// Draw implements Widget.
+func (l *Label) Draw(d *Device) image.Image {
+	l.stateMutex.Lock()
+	defer l.stateMutex.Unlock()
+	return textImage(d, l.Bounds().Size(), l.text, l.fg, l.bg)
+}
+
+// HandleEvent implements Widget.  Labels ignore events.
+func (l *Label) HandleEvent(Event) bool {
+	return false
+}
+
+// IconButton is a widget that shows an image and calls a function
+// when tapped.  It's highlighted while it's being touched.
+type IconButton struct {
+	WidgetBase
+	stateMutex sync.Mutex
+	icon       image.Image
+	bg         color.Color
+	pressed    bool
+	f          func()
+}
+
+// NewIconButton creates a new IconButton.  Icons larger than the
+// button are scaled down to fit.
+func NewIconButton(icon image.Image, bg color.Color, f func()) *IconButton {
+	return &IconButton{icon: icon, bg: bg, f: f}
+}
+
+// SetIcon changes the button's image and redraws it.
+func (b *IconButton) SetIcon(icon image.Image) {
+	b.stateMutex.Lock()
+	b.icon = icon
+	b.stateMutex.Unlock()
+	b.Redraw()
+}
+
+// Draw implements Widget.
+func (b *IconButton) Draw(d *Device) image.Image {
+	b.stateMutex.Lock()
+	defer b.stateMutex.Unlock()
+
+	size := b.Bounds().Size()
+	im := solidImage(size, b.bg)
+	if b.icon != nil {
+		drawIcon(im, im.Bounds(), b.icon)
+	}
+	if b.pressed {
+		draw.Draw(im, im.Bounds(), &image.Uniform{color.RGBA{64, 64, 64, 64}}, image.Point{}, draw.Over)
+	}
+	return im
+}
+
+// HandleEvent implements Widget.
+func (b *IconButton) HandleEvent(e Event) bool {
+	t, ok := e.(TouchEvent)
+	if !ok || t.Phase == TouchPhaseMove {
+		return false
+	}
+
+	b.stateMutex.Lock()
+	b.pressed = t.Phase == TouchPhaseBegin
+	f := b.f
+	b.stateMutex.Unlock()
+
+	if t.Phase == TouchPhaseEnd && touchedInside(b, t) && f != nil {
+		f()
+	}
+	return true
+}
+
+// KeyButton is a widget that shows a label, an icon, or both, and
+// calls a function when tapped.  It's highlighted while it's being
+// touched.  It can also show a status line, such as the output of a
+// CommandAction, across its bottom.
+type KeyButton struct {
+	WidgetBase
+	stateMutex  sync.Mutex
+	label       string
+	icon        image.Image
+	fg, bg      color.Color
+	status      string
+	statusColor color.Color
+	pressed     bool
+	f           func()
+}
+
+// NewKeyButton creates a new KeyButton.  Either label or icon may be
+// empty; when both are given, the icon is drawn above the label.
+func NewKeyButton(label string, icon image.Image, fg, bg color.Color, f func()) *KeyButton {
+	return &KeyButton{label: label, icon: icon, fg: fg, bg: bg, f: f}
+}
+
+// SetLabel changes the button's label and redraws it.
+func (b *KeyButton) SetLabel(label string) {
+	b.stateMutex.Lock()
+	b.label = label
+	b.stateMutex.Unlock()
+	b.Redraw()
+}
+
+// SetStatus shows a line of text on a colored band across the bottom
+// of the button, or across all of it if the button has no label or
+// icon, and redraws it.  A nil color removes the status.
+func (b *KeyButton) SetStatus(text string, c color.Color) {
+	b.stateMutex.Lock()
+	b.status, b.statusColor = text, c
+	b.stateMutex.Unlock()
+	b.Redraw()
+}
+
+// Draw implements Widget.
+func (b *KeyButton) Draw(d *Device) image.Image {
+	b.stateMutex.Lock()
+	defer b.stateMutex.Unlock()
+
+	size := b.Bounds().Size()
+	im := solidImage(size, b.bg)
+	area := im.Bounds()
+	if b.statusColor != nil {
+		statusArea := area
+		if b.label != "" || b.icon != nil {
+			statusArea.Min.Y = size.Y * 2 / 3
+			area.Max.Y = statusArea.Min.Y
+		}
+		var status image.Image = solidImage(statusArea.Size(), b.statusColor)
+		if b.status != "" {
+			status = textImage(d, statusArea.Size(), b.status, b.fg, b.statusColor)
+		}
+		draw.Draw(im, statusArea, status, image.Point{}, draw.Src)
+	}
+
+	iconArea := area
+	if b.label != "" {
+		labelArea := iconArea
+		if b.icon != nil {
+			labelArea.Min.Y = area.Min.Y + area.Dy()*2/3
+			iconArea.Max.Y = labelArea.Min.Y
+		}
+		text := textImage(d, labelArea.Size(), b.label, b.fg, b.bg)
+		draw.Draw(im, labelArea, text, image.Point{}, draw.Src)
+	}
+	if b.icon != nil {
+		drawIcon(im, iconArea, b.icon)
+	}
+	if b.pressed {
+		draw.Draw(im, im.Bounds(), &image.Uniform{color.RGBA{64, 64, 64, 64}}, image.Point{}, draw.Over)
+	}
+	return im
+}
+
+// HandleEvent implements Widget.
+func (b *KeyButton) HandleEvent(e Event) bool {
+	t, ok := e.(TouchEvent)
+	if !ok || t.Phase == TouchPhaseMove {
+		return false
+	}
+
+	b.stateMutex.Lock()
+	b.pressed = t.Phase == TouchPhaseBegin
+	f := b.f
+	b.stateMutex.Unlock()
+
+	if t.Phase == TouchPhaseEnd && touchedInside(b, t) && f != nil {
+		f()
+	}
+	return true
+}
+
+// Toggle is a widget that switches on and off each time it's tapped.
+type Toggle struct {
+	WidgetBase
+	stateMutex sync.Mutex
+	text       string
+	on         bool
+	onColor    color.Color
+	offColor   color.Color
+	f          func(bool)
+}
+
+// NewToggle creates a new Toggle, which shows text on a background
+// of onColor or offColor.  f is called with the new state whenever
+// the toggle is tapped.
+func NewToggle(text string, on bool, onColor, offColor color.Color, f func(bool)) *Toggle {
+	return &Toggle{text: text, on: on, onColor: onColor, offColor: offColor, f: f}
+}
+
+// On returns true if the toggle is on.
+func (t *Toggle) On() bool {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	return t.on
+}
+
+// SetOn turns the toggle on or off and redraws it, without calling
+// its function.
+func (t *Toggle) SetOn(on bool) {
+	t.stateMutex.Lock()
+	t.on = on
+	t.stateMutex.Unlock()
+	t.Redraw()
+}
+
+// Draw implements Widget.
+func (t *Toggle) Draw(d *Device) image.Image {
+	t.stateMutex.Lock()
+	defer t.stateMutex.Unlock()
+	bg := t.offColor
+	if t.on {
+		bg = t.onColor
+	}
+	return textImage(d, t.Bounds().Size(), t.text, color.White, bg)
+}
+
+// HandleEvent implements Widget.
+func (t *Toggle) HandleEvent(e Event) bool {
+	te, ok := e.(TouchEvent)
+	if !ok || te.Phase != TouchPhaseBegin {
+		return false
+	}
+
+	t.stateMutex.Lock()
+	t.on = !t.on
+	on, f := t.on, t.f
+	t.stateMutex.Unlock()
+
+	if f != nil {
+		f(on)
+	}
+	return true
+}
+
+// Momentary is a widget that is on only while it's being touched,
+// like a push-to-talk button.
+type Momentary struct {
+	WidgetBase
+	stateMutex sync.Mutex
+	text       string
+	pressed    bool
+	onColor    color.Color
+	offColor   color.Color
+	f          func(bool)
+}
+
+// NewMomentary creates a new Momentary, which shows text on a
+// background of onColor while it's touched and offColor otherwise.
+// f is called with true when it's touched and false when released.
+func NewMomentary(text string, onColor, offColor color.Color, f func(bool)) *Momentary {
+	return &Momentary{text: text, onColor: onColor, offColor: offColor, f: f}
+}
+
+// Pressed returns true if the widget is being touched.
+func (m *Momentary) Pressed() bool {
+	m.stateMutex.Lock()
+	defer m.stateMutex.Unlock()
+	return m.pressed
+}
+
+// Draw implements Widget.
+func (m *Momentary) Draw(d *Device) image.Image {
+	m.stateMutex.Lock()
+	defer m.stateMutex.Unlock()
+	bg := m.offColor
+	if m.pressed {
+		bg = m.onColor
+	}
+	return textImage(d, m.Bounds().Size(), m.text, color.White, bg)
+}
+
+// HandleEvent implements Widget.
+func (m *Momentary) HandleEvent(e Event) bool {
+	t, ok := e.(TouchEvent)
+	if !ok || t.Phase == TouchPhaseMove {
+		return false
+	}
+
+	m.stateMutex.Lock()
+	m.pressed = t.Phase == TouchPhaseBegin
+	pressed, f := m.pressed, m.f
+	m.stateMutex.Unlock()
+
+	if f != nil {
+		f(pressed)
+	}
+	return true
+}
+
+// AnalogGauge is a widget that shows a Watched value as a dial, with
+// its name and current value.  When a knob is attached to it (with
+// WidgetContainer.AttachKnob), turning the knob adjusts the value and
+// clicking the knob resets it.
+type AnalogGauge[T Number] struct {
+	WidgetBase
+	name    string
+	value   *Watched[T]
+	fg, bg  color.Color
+	watcher *Binding
+
+	stateMutex sync.Mutex
+	format     string
+}
+
+// NewAnalogGauge creates a new AnalogGauge for a value.  The gauge
+// redraws itself whenever the value changes.
+func NewAnalogGauge[T Number](name string, value *Watched[T], fg, bg color.Color) *AnalogGauge[T] {
+	g := &AnalogGauge[T]{name: name, value: value, fg: fg, bg: bg}
+	g.watcher = value.AddWatcher(func(T) { g.Redraw() })
+	return g
+}
+
+// SetFormat sets the fmt format used to show the value, such as
+// "%.0f%%".  By default, integers are shown as-is and floats with two
+// decimal places.
+func (g *AnalogGauge[T]) SetFormat(format string) {
+	g.stateMutex.Lock()
+	g.format = format
+	g.stateMutex.Unlock()
+	g.Redraw()
+}
+
+// Close stops the gauge from watching its value.
+func (g *AnalogGauge[T]) Close() {
+	g.watcher.Unbind()
+}
+
+// Draw implements Widget.
+func (g *AnalogGauge[T]) Draw(d *Device) image.Image {
+	size := g.Bounds().Size()
+	im := solidImage(size, g.bg)
+
+	// The name goes across the top, the dial below it, and the
+	// value in the middle of the dial.
+	nameHeight := size.Y / 4
+	draw.Draw(im, image.Rect(0, 0, size.X, nameHeight), textImage(d, image.Pt(size.X, nameHeight), g.name, g.fg, g.bg), image.Point{}, draw.Src)
+
+	dial := image.Rect(0, nameHeight, size.X, size.Y)
+	radius := float64(min(dial.Dx(), dial.Dy())) * 0.45
+	center := dial.Min.Add(dial.Size().Div(2))
+	track := color.RGBA{64, 64, 64, 255}
+	DrawGauge(im, center, radius, radius*0.2, 225, 270, g.value.Position(), g.fg, track)
+
+	g.stateMutex.Lock()
+	format := g.format
+	g.stateMutex.Unlock()
+
+	v := g.value.Get()
+	var text string
+	switch {
+	case format != "":
+		text = fmt.Sprintf(format, v)
+	case g.value.isFloat():
+		text = fmt.Sprintf("%.2f", float64(v))
+	default:
+		text = fmt.Sprint(v)
+	}
+	box := int(radius)
+	valueImage := textImage(d, image.Pt(box, box/2), text, g.fg, g.bg)
+	at := center.Sub(image.Pt(box/2, -box/4))
+	draw.Draw(im, valueImage.Bounds().Add(at), valueImage, image.Point{}, draw.Src)
+	return im
+}
+
+// HandleEvent implements Widget.
+func (g *AnalogGauge[T]) HandleEvent(e Event) bool {
+	switch e := e.(type) {
+	case KnobEvent:
+		g.value.Add(e.Delta)
+	case ButtonEvent:
+		if e.State == ButtonDown {
+			g.value.Reset()
+		}
+	}
+	// The value's watcher redraws the gauge.
+	return false
+}
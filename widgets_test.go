@@ -0,0 +1,34 @@
+package loupedeck
+
+import (
+	"image"
+	"image/color"
+	"sync"
+	"testing"
+)
+
+func TestAnalogGaugeFormat(t *testing.T) {
+	d := CreateDevice(&SerialWebSockConn{Product: "0004"})
+	if err := d.SetDefaultFont(); err != nil {
+		t.Fatal(err)
+	}
+	v := NewWatched(50.0, 0, 100, 1)
+	g := NewAnalogGauge("Volume", v, color.White, color.Black)
+	defer g.Close()
+	g.SetBounds(image.Rect(0, 0, 90, 90))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, f := range []string{"%.0f%%", "%.1f", ""} {
+			g.SetFormat(f)
+		}
+	}()
+	for i := 0; i < 3; i++ {
+		if im := g.Draw(d); im.Bounds().Size() != image.Pt(90, 90) {
+			t.Errorf("Draw() returned a %v image, want 90x90", im.Bounds().Size())
+		}
+	}
+	wg.Wait()
+}